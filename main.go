@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/ryu-ch/terraform-ls-mcp/pkg/mcp"
 	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
@@ -21,42 +23,83 @@ func main() {
 
 	switch os.Args[1] {
 	case "serve":
-		serve()
+		serve(os.Args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
 		os.Exit(1)
 	}
 }
 
-func serve() {
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	maxSessions := flags.Int("max-sessions", terraform.DefaultMaxSessions, "Maximum number of terraform-ls processes, one per workspace")
+	idleTimeout := flags.Duration("idle-timeout", terraform.DefaultIdleTimeout, "Shut down terraform-ls for a workspace after it has been unused this long")
+	workers := flags.Int("workers", mcp.DefaultWorkers, "Maximum number of requests handled at once")
+	httpAddr := flags.String("http", "", "Serve the MCP Streamable HTTP transport on this address, such as :8080, instead of stdin/stdout")
+	sseAddr := flags.String("sse", "", "Serve the legacy MCP HTTP+SSE transport on this address, such as :8081, instead of stdin/stdout")
+	sessionTimeout := flags.Duration("session-timeout", mcp.DefaultHTTPSessionTimeout, "End a Streamable HTTP session after it has been unused this long")
+	flags.Parse(args)
+
 	ctx := context.Background()
-	
-	// Initialize terraform-ls client
-	tfClient, err := terraform.NewClient()
-	if err != nil {
-		log.Fatalf("Failed to initialize terraform-ls client: %v", err)
-	}
-	defer tfClient.Close()
+
+	// terraform-ls is started lazily, once per workspace
+	sessions := terraform.NewSessionManager(terraform.SessionOptions{
+		MaxSessions: *maxSessions,
+		IdleTimeout: *idleTimeout,
+	})
+	defer sessions.Close()
 
 	// Initialize MCP server
-	server := mcp.NewServer(tfClient)
+	server := mcp.NewServer(sessions)
+
+	if *httpAddr != "" || *sseAddr != "" {
+		serveHTTP(*httpAddr, *sseAddr, *sessionTimeout, server)
+		return
+	}
 
 	// Handle stdin/stdout communication
-	decoder := json.NewDecoder(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
-
-	for {
-		var request mcp.Request
-		if err := decoder.Decode(&request); err != nil {
-			log.Printf("Failed to decode request: %v", err)
-			break
-		}
+	if err := server.Serve(ctx, os.Stdin, os.Stdout, *workers); err != nil {
+		log.Printf("Failed to serve: %v", err)
+	}
+}
 
-		response := server.HandleRequest(ctx, request)
-		
-		if err := encoder.Encode(response); err != nil {
-			log.Printf("Failed to encode response: %v", err)
-			break
+// serveHTTP serves the Streamable HTTP endpoint at /mcp on httpAddr and the
+// legacy /sse and /messages endpoints on sseAddr until a listener fails. The
+// two may share an address.
+func serveHTTP(httpAddr, sseAddr string, sessionTimeout time.Duration, server *mcp.Server) {
+	muxes := make(map[string]*http.ServeMux)
+	mux := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
 		}
+		return muxes[addr]
+	}
+
+	if httpAddr != "" {
+		handler := mcp.NewStreamableHTTPHandler(server)
+		handler.IdleTimeout = sessionTimeout
+		defer handler.Close()
+
+		mux(httpAddr).Handle("/mcp", handler)
+		log.Printf("Serving MCP over HTTP on %s/mcp", httpAddr)
+	}
+
+	if sseAddr != "" {
+		handler := mcp.NewSSEHandler(server)
+		defer handler.Close()
+
+		mux(sseAddr).Handle("/sse", handler)
+		mux(sseAddr).Handle("/messages", handler)
+		log.Printf("Serving MCP over HTTP+SSE on %s/sse", sseAddr)
 	}
-}
\ No newline at end of file
+
+	errs := make(chan error, len(muxes))
+	for addr, mux := range muxes {
+		go func() {
+			errs <- http.ListenAndServe(addr, mux)
+		}()
+	}
+	if err := <-errs; err != nil {
+		log.Printf("Failed to serve HTTP: %v", err)
+	}
+}
@@ -4,15 +4,25 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os/exec"
-	"strconv"
-	"strings"
 	"sync"
 	"sync/atomic"
 )
 
+var (
+	// ErrClosed is returned for requests that cannot complete because the
+	// connection to the server has been closed
+	ErrClosed = errors.New("lsp client closed")
+
+	// ErrUnknownResponseID is reported when the server sends a response whose
+	// ID does not match any in-flight request
+	ErrUnknownResponseID = errors.New("response for unknown request ID")
+)
+
 // LSP Request/Response structures
 type Request struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -40,113 +50,229 @@ type Notification struct {
 	Params  interface{} `json:"params,omitempty"`
 }
 
+// NotificationHandler handles a notification sent by the server. Handlers
+// run on the goroutine that reads from the server, in the order the
+// notifications arrive, so they must return quickly and must not wait for
+// responses to requests.
+type NotificationHandler func(method string, params json.RawMessage)
+
+// RequestHandler answers a request sent by the server. The returned value
+// becomes the result of the response. Returning an *Error sends that error;
+// any other error is reported as an internal error.
+type RequestHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// subscription is a registered notification handler
+type subscription struct {
+	handler NotificationHandler
+}
+
+// pendingRequest tracks a request that is waiting for its response. A
+// request abandoned by its caller stays registered as cancelled until the
+// server answers it, so the late response is not mistaken for a stray one.
+type pendingRequest struct {
+	ch        chan *Response
+	cancelled bool
+}
+
 // Client represents an LSP client
 type Client struct {
-	cmd     *exec.Cmd
-	stdin   io.WriteCloser
-	stdout  io.ReadCloser
-	stderr  io.ReadCloser
-	
-	reqID     int64
-	responses map[interface{}]chan Response
-	mu        sync.RWMutex
-	
-	ctx    context.Context
-	cancel context.CancelFunc
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+
+	reqID   int64
+	pending map[ID]*pendingRequest
+	mu      sync.Mutex
+	writeMu sync.Mutex
+
+	errorHandler  func(error)
+	subscriptions map[string][]*subscription
+	handlers      map[string]RequestHandler
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	readErr error
 }
 
 // NewClient creates a new LSP client for terraform-ls
 func NewClient() (*Client, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	
-	cmd := exec.CommandContext(ctx, "terraform-ls", "serve")
-	
+	cmd := exec.Command("terraform-ls", "serve")
+
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		cancel()
 		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
-	
+
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		cancel()
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
-	
+
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		cancel()
 		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
-	
+
 	if err := cmd.Start(); err != nil {
-		cancel()
 		return nil, fmt.Errorf("failed to start terraform-ls: %w", err)
 	}
-	
-	client := &Client{
-		cmd:       cmd,
-		stdin:     stdin,
-		stdout:    stdout,
-		stderr:    stderr,
-		responses: make(map[interface{}]chan Response),
-		ctx:       ctx,
-		cancel:    cancel,
-	}
-	
-	go client.readResponses()
-	
+
+	// Drain stderr so a chatty server never blocks on a full pipe
+	go io.Copy(io.Discard, stderr)
+
+	client := NewClientFromStreams(stdout, stdin)
+	client.cmd = cmd
+	client.stderr = stderr
+
 	return client, nil
 }
 
+// NewClientFromStreams creates an LSP client that talks to a server over the
+// given streams. It is used for servers that are not started by the client,
+// such as in-process fakes in tests.
+func NewClientFromStreams(stdout io.ReadCloser, stdin io.WriteCloser) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	client := &Client{
+		stdin:         stdin,
+		stdout:        stdout,
+		pending:       make(map[ID]*pendingRequest),
+		subscriptions: make(map[string][]*subscription),
+		handlers:      defaultRequestHandlers(),
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+
+	go client.readMessages()
+
+	return client
+}
+
+// SetErrorHandler sets the function called for protocol errors that cannot
+// be attributed to a specific request, such as responses with unknown IDs.
+// By default they are logged.
+func (c *Client) SetErrorHandler(handler func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errorHandler = handler
+}
+
+// OnNotification registers a handler for server notifications with the
+// given method, such as textDocument/publishDiagnostics or $/progress.
+// Several handlers may be registered for the same method. The returned
+// function removes the handler.
+func (c *Client) OnNotification(method string, handler NotificationHandler) func() {
+	sub := &subscription{handler: handler}
+
+	c.mu.Lock()
+	c.subscriptions[method] = append(c.subscriptions[method], sub)
+	c.mu.Unlock()
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		subs := c.subscriptions[method]
+		for i, s := range subs {
+			if s == sub {
+				c.subscriptions[method] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(c.subscriptions[method]) == 0 {
+			delete(c.subscriptions, method)
+		}
+	}
+}
+
+// OnRequest registers the handler for requests from the server with the
+// given method, replacing any previous handler including the default one.
+// Handlers run on their own goroutine and may send requests to the server.
+func (c *Client) OnRequest(method string, handler RequestHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if handler == nil {
+		delete(c.handlers, method)
+		return
+	}
+	c.handlers[method] = handler
+}
+
+// Done returns a channel that is closed once the connection to the server
+// has been lost or closed
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
 // Close closes the LSP client
 func (c *Client) Close() error {
 	c.cancel()
-	
+
 	if c.stdin != nil {
 		c.stdin.Close()
 	}
-	
+	if c.stdout != nil {
+		c.stdout.Close()
+	}
+
 	if c.cmd != nil && c.cmd.Process != nil {
-		return c.cmd.Process.Kill()
+		err := c.cmd.Process.Kill()
+		c.cmd.Wait()
+		return err
 	}
-	
+
 	return nil
 }
 
 // SendRequest sends a request to the LSP server and returns the response
 func (c *Client) SendRequest(ctx context.Context, method string, params interface{}) (*Response, error) {
-	id := atomic.AddInt64(&c.reqID, 1)
-	
+	id := NewNumberID(atomic.AddInt64(&c.reqID, 1))
+
+	pending := &pendingRequest{
+		ch: make(chan *Response, 1),
+	}
+
+	c.mu.Lock()
+	if c.readErr != nil {
+		err := c.readErr
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.pending[id] = pending
+	c.mu.Unlock()
+
 	request := Request{
 		JSONRPC: "2.0",
 		ID:      id,
 		Method:  method,
 		Params:  params,
 	}
-	
-	respChan := make(chan Response, 1)
-	c.mu.Lock()
-	c.responses[id] = respChan
-	c.mu.Unlock()
-	
-	defer func() {
+
+	if err := c.writeMessage(request); err != nil {
 		c.mu.Lock()
-		delete(c.responses, id)
+		delete(c.pending, id)
 		c.mu.Unlock()
-	}()
-	
-	if err := c.writeMessage(request); err != nil {
 		return nil, fmt.Errorf("failed to write request: %w", err)
 	}
-	
+
 	select {
-	case response := <-respChan:
-		return &response, nil
+	case response := <-pending.ch:
+		return response, nil
 	case <-ctx.Done():
+		c.mu.Lock()
+		pending.cancelled = true
+		c.mu.Unlock()
+		c.cancelRequest(id)
 		return nil, ctx.Err()
-	case <-c.ctx.Done():
-		return nil, c.ctx.Err()
+	case <-c.done:
+		c.mu.Lock()
+		err := c.readErr
+		c.mu.Unlock()
+		return nil, err
 	}
 }
 
@@ -157,81 +283,163 @@ func (c *Client) SendNotification(method string, params interface{}) error {
 		Method:  method,
 		Params:  params,
 	}
-	
+
 	return c.writeMessage(notification)
 }
 
-func (c *Client) writeMessage(message interface{}) error {
-	data, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
-	
-	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
-	
-	if _, err := c.stdin.Write([]byte(header)); err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
+// cancelRequest tells the server that the client is no longer interested in
+// the response to id. Failures are ignored because cancellation is advisory.
+func (c *Client) cancelRequest(id ID) {
+	select {
+	case <-c.done:
+		return
+	default:
 	}
-	
-	if _, err := c.stdin.Write(data); err != nil {
-		return fmt.Errorf("failed to write data: %w", err)
+	c.SendNotification("$/cancelRequest", map[string]interface{}{"id": id})
+}
+
+func (c *Client) writeMessage(message interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return WriteMessage(c.stdin, message)
+}
+
+func (c *Client) reportError(err error) {
+	c.mu.Lock()
+	handler := c.errorHandler
+	c.mu.Unlock()
+
+	if handler != nil {
+		handler(err)
+		return
 	}
-	
-	return nil
+	log.Printf("lsp: %v", err)
 }
 
-func (c *Client) readResponses() {
+func (c *Client) readMessages() {
 	reader := bufio.NewReader(c.stdout)
-	
+
+	var err error
 	for {
-		// Read the Content-Length header
-		line, err := reader.ReadString('\n')
+		var data []byte
+		data, err = ReadMessage(reader)
 		if err != nil {
 			break
 		}
-		
-		line = strings.TrimSpace(line)
-		if !strings.HasPrefix(line, "Content-Length:") {
-			continue
-		}
-		
-		parts := strings.Split(line, ":")
-		if len(parts) != 2 {
+
+		var msg message
+		if jsonErr := json.Unmarshal(data, &msg); jsonErr != nil {
+			c.reportError(fmt.Errorf("failed to decode message: %w", jsonErr))
 			continue
 		}
-		
-		length, err := strconv.Atoi(strings.TrimSpace(parts[1]))
-		if err != nil {
-			continue
+
+		c.dispatch(&msg)
+	}
+
+	if errors.Is(err, io.EOF) || c.ctx.Err() != nil {
+		err = ErrClosed
+	} else {
+		err = fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+
+	c.mu.Lock()
+	c.readErr = err
+	c.pending = make(map[ID]*pendingRequest)
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func (c *Client) dispatch(msg *message) {
+	switch {
+	case msg.isResponse():
+		c.handleResponse(msg)
+	case msg.isNotification():
+		c.handleNotification(msg)
+	case msg.isRequest():
+		c.mu.Lock()
+		handler := c.handlers[msg.Method]
+		c.mu.Unlock()
+
+		go c.handleRequest(msg, handler)
+	}
+}
+
+func (c *Client) handleRequest(msg *message, handler RequestHandler) {
+	reply := message{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+	}
+
+	if handler == nil {
+		reply.Error = &Error{
+			Code:    CodeMethodNotFound,
+			Message: fmt.Sprintf("method not found: %s", msg.Method),
 		}
-		
-		// Skip the empty line after headers
-		_, err = reader.ReadString('\n')
-		if err != nil {
-			break
+	} else if result, err := handler(c.ctx, msg.Params); err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
 		}
-		
-		// Read the JSON content
-		jsonData := make([]byte, length)
-		_, err = io.ReadFull(reader, jsonData)
+		reply.Error = rpcErr
+	} else {
+		data, err := json.Marshal(result)
 		if err != nil {
-			continue
+			reply.Error = &Error{Code: CodeInternalError, Message: fmt.Sprintf("failed to marshal result: %v", err)}
+		} else {
+			reply.Result = data
 		}
-		
-		var response Response
-		if err := json.Unmarshal(jsonData, &response); err != nil {
-			continue
-		}
-		
-		c.mu.RLock()
-		respChan, exists := c.responses[response.ID]
-		c.mu.RUnlock()
-		
-		if exists {
-			select {
-			case respChan <- response:
-			default:
-			}
+	}
+
+	if err := c.writeMessage(reply); err != nil {
+		c.reportError(fmt.Errorf("failed to answer %s request: %w", msg.Method, err))
+	}
+}
+
+func (c *Client) handleNotification(msg *message) {
+	c.mu.Lock()
+	subs := c.subscriptions[msg.Method]
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.handler(msg.Method, msg.Params)
+	}
+}
+
+func (c *Client) handleResponse(msg *message) {
+	if msg.ID == nil {
+		if msg.Error != nil {
+			c.reportError(fmt.Errorf("server error without request ID: %w", msg.Error))
+		} else {
+			c.reportError(errors.New("response without request ID"))
 		}
+		return
 	}
-}
\ No newline at end of file
+
+	c.mu.Lock()
+	pending, exists := c.pending[*msg.ID]
+	cancelled := exists && pending.cancelled
+	if exists {
+		delete(c.pending, *msg.ID)
+	}
+	c.mu.Unlock()
+
+	if !exists {
+		c.reportError(fmt.Errorf("%w: %s", ErrUnknownResponseID, msg.ID))
+		return
+	}
+	if cancelled {
+		return
+	}
+
+	response := &Response{
+		JSONRPC: msg.JSONRPC,
+		ID:      *msg.ID,
+		Error:   msg.Error,
+	}
+	if len(msg.Result) > 0 {
+		response.Result = msg.Result
+	}
+
+	pending.ch <- response
+}
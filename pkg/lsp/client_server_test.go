@@ -0,0 +1,311 @@
+package lsp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp"
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp/lsptest"
+)
+
+// newTestClient starts an in-process fake server and returns both sides
+func newTestClient(t *testing.T) (*lsptest.Server, *lsp.Client) {
+	t.Helper()
+
+	server := lsptest.NewServer()
+	t.Cleanup(func() { server.Close() })
+
+	return server, server.Client()
+}
+
+func TestClient_SendRequestDeliversResponse(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"capabilities": map[string]interface{}{"hoverProvider": true}}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := client.SendRequest(ctx, "initialize", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Expected response, got error: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("Expected no error, got: %v", resp.Error)
+	}
+
+	var result struct {
+		Capabilities struct {
+			HoverProvider bool `json:"hoverProvider"`
+		} `json:"capabilities"`
+	}
+	if err := resp.UnmarshalResult(&result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if !result.Capabilities.HoverProvider {
+		t.Error("Expected hoverProvider to be true")
+	}
+}
+
+func TestClient_ErrorResponse(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/hover", func(params json.RawMessage) (interface{}, error) {
+		return nil, &lsp.Error{Code: lsp.CodeInvalidParams, Message: "bad position"}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := client.SendRequest(ctx, "textDocument/hover", nil)
+	if err != nil {
+		t.Fatalf("Expected response, got error: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != lsp.CodeInvalidParams {
+		t.Errorf("Expected invalid params error, got: %v", resp.Error)
+	}
+}
+
+func TestClient_OutOfOrderResponses(t *testing.T) {
+	server, client := newTestClient(t)
+
+	release := make(chan struct{})
+	server.Handle("slow", func(params json.RawMessage) (interface{}, error) {
+		<-release
+		return "slow", nil
+	})
+	server.Handle("fast", func(params json.RawMessage) (interface{}, error) {
+		return "fast", nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	slowResult := make(chan string, 1)
+	go func() {
+		resp, err := client.SendRequest(ctx, "slow", nil)
+		if err != nil {
+			slowResult <- err.Error()
+			return
+		}
+		var s string
+		resp.UnmarshalResult(&s)
+		slowResult <- s
+	}()
+
+	server.WaitFor("slow", 1, ctx.Done())
+
+	resp, err := client.SendRequest(ctx, "fast", nil)
+	if err != nil {
+		t.Fatalf("Expected fast response, got error: %v", err)
+	}
+	var fast string
+	if err := resp.UnmarshalResult(&fast); err != nil || fast != "fast" {
+		t.Errorf("Expected 'fast', got: %q (%v)", fast, err)
+	}
+
+	close(release)
+	if got := <-slowResult; got != "slow" {
+		t.Errorf("Expected 'slow', got: %q", got)
+	}
+}
+
+func TestClient_UnknownResponseID(t *testing.T) {
+	server, client := newTestClient(t)
+
+	errs := make(chan error, 1)
+	client.SetErrorHandler(func(err error) {
+		errs <- err
+	})
+
+	if err := server.Send(map[string]interface{}{"jsonrpc": "2.0", "id": 99, "result": nil}); err != nil {
+		t.Fatalf("Failed to send response: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, lsp.ErrUnknownResponseID) {
+			t.Errorf("Expected lsp.ErrUnknownResponseID, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an error for the unknown response ID")
+	}
+}
+
+func TestClient_ContextCancellation(t *testing.T) {
+	server, client := newTestClient(t)
+
+	errs := make(chan error, 1)
+	client.SetErrorHandler(func(err error) {
+		errs <- err
+	})
+
+	release := make(chan struct{})
+	server.Handle("slow", func(params json.RawMessage) (interface{}, error) {
+		<-release
+		return nil, &lsp.Error{Code: -32800, Message: "cancelled"}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		server.WaitFor("slow", 1, nil)
+		cancel()
+	}()
+
+	if _, err := client.SendRequest(ctx, "slow", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer waitCancel()
+	if cancels := server.WaitFor("$/cancelRequest", 1, waitCtx.Done()); len(cancels) != 1 {
+		t.Fatal("Expected $/cancelRequest to be sent")
+	}
+
+	// The late response for the cancelled request must not be reported
+	close(release)
+	select {
+	case err := <-errs:
+		t.Errorf("Expected no error for cancelled request, got: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClient_ConnectionClosed(t *testing.T) {
+	server, client := newTestClient(t)
+	blocked := make(chan struct{})
+	defer close(blocked)
+	server.Handle("never", func(params json.RawMessage) (interface{}, error) {
+		<-blocked
+		return nil, nil
+	})
+
+	go func() {
+		server.WaitFor("never", 1, nil)
+		server.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := client.SendRequest(ctx, "never", nil); !errors.Is(err, lsp.ErrClosed) {
+		t.Errorf("Expected lsp.ErrClosed, got: %v", err)
+	}
+}
+
+func TestClient_OnNotification(t *testing.T) {
+	server, client := newTestClient(t)
+
+	received := make(chan string, 4)
+	unsubscribe := client.OnNotification("window/logMessage", func(method string, params json.RawMessage) {
+		var p struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(params, &p)
+		received <- p.Message
+	})
+
+	server.Notify("$/progress", map[string]interface{}{"token": "t"})
+	server.Notify("window/logMessage", map[string]interface{}{"type": 3, "message": "first"})
+
+	select {
+	case msg := <-received:
+		if msg != "first" {
+			t.Errorf("Expected 'first', got: %q", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected window/logMessage notification to be dispatched")
+	}
+
+	unsubscribe()
+
+	// A request round trip guarantees that any later notification has been
+	// read before we check that nothing was delivered
+	server.Handle("sync", func(params json.RawMessage) (interface{}, error) { return nil, nil })
+	server.Notify("window/logMessage", map[string]interface{}{"type": 3, "message": "second"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := client.SendRequest(ctx, "sync", nil); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	select {
+	case msg := <-received:
+		t.Errorf("Expected no notification after unsubscribe, got: %q", msg)
+	default:
+	}
+}
+
+func TestClient_DefaultRequestHandlers(t *testing.T) {
+	server, _ := newTestClient(t)
+
+	reply, err := server.Call("workspace/configuration", map[string]interface{}{
+		"items": []map[string]string{{"section": "terraform"}, {"section": "terraform-ls"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to call client: %v", err)
+	}
+	if reply.Error != nil {
+		t.Fatalf("Expected no error, got: %v", reply.Error)
+	}
+	if string(reply.Result) != "[null,null]" {
+		t.Errorf("Expected [null,null], got: %s", reply.Result)
+	}
+
+	reply, err = server.Call("window/workDoneProgress/create", map[string]string{"token": "abc"})
+	if err != nil {
+		t.Fatalf("Failed to call client: %v", err)
+	}
+	if reply.Error != nil || string(reply.Result) != "null" {
+		t.Errorf("Expected null result, got: %s (%v)", reply.Result, reply.Error)
+	}
+
+	reply, err = server.Call("custom/unknown", nil)
+	if err != nil {
+		t.Fatalf("Failed to call client: %v", err)
+	}
+	if reply.Error == nil || reply.Error.Code != lsp.CodeMethodNotFound {
+		t.Errorf("Expected method not found error, got: %v", reply.Error)
+	}
+}
+
+func TestClient_OnRequest(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("workspace/symbol", func(params json.RawMessage) (interface{}, error) {
+		return []string{"var.region"}, nil
+	})
+
+	// Handlers run on their own goroutine, so they may talk to the server
+	client.OnRequest("client/registerCapability", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		resp, err := client.SendRequest(ctx, "workspace/symbol", nil)
+		if err != nil {
+			return nil, err
+		}
+		var symbols []string
+		resp.UnmarshalResult(&symbols)
+		return map[string]int{"symbols": len(symbols)}, nil
+	})
+	client.OnRequest("window/showMessageRequest", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, &lsp.Error{Code: lsp.CodeInvalidParams, Message: "no actions"}
+	})
+
+	reply, err := server.Call("client/registerCapability", map[string]interface{}{"registrations": []interface{}{}})
+	if err != nil {
+		t.Fatalf("Failed to call client: %v", err)
+	}
+	if string(reply.Result) != `{"symbols":1}` {
+		t.Errorf("Expected {\"symbols\":1}, got: %s (%v)", reply.Result, reply.Error)
+	}
+
+	reply, err = server.Call("window/showMessageRequest", nil)
+	if err != nil {
+		t.Fatalf("Failed to call client: %v", err)
+	}
+	if reply.Error == nil || reply.Error.Code != lsp.CodeInvalidParams {
+		t.Errorf("Expected invalid params error, got: %v", reply.Error)
+	}
+}
@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -67,7 +68,7 @@ func TestError_Structure(t *testing.T) {
 func TestClientCreation_Structure(t *testing.T) {
 	// Test that we can create the basic structure
 	// This test doesn't actually create a client since it would require terraform-ls
-	
+
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
@@ -80,4 +81,52 @@ func TestClientCreation_Structure(t *testing.T) {
 	case <-time.After(2 * time.Second):
 		t.Error("Context should have been cancelled")
 	}
-}
\ No newline at end of file
+}
+
+func TestID_JSON(t *testing.T) {
+	cases := []struct {
+		input string
+		want  ID
+	}{
+		{`1`, NewNumberID(1)},
+		{`42.0`, NewNumberID(42)},
+		{`"abc"`, NewStringID("abc")},
+		{`"7"`, NewStringID("7")},
+	}
+
+	for _, tc := range cases {
+		var id ID
+		if err := json.Unmarshal([]byte(tc.input), &id); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", tc.input, err)
+		}
+		if id != tc.want {
+			t.Errorf("Expected %v for %s, got: %v", tc.want, tc.input, id)
+		}
+	}
+
+	var id ID
+	if err := json.Unmarshal([]byte(`1.5`), &id); err == nil {
+		t.Error("Expected error for non-integer ID")
+	}
+
+	data, err := json.Marshal(NewStringID("x"))
+	if err != nil || string(data) != `"x"` {
+		t.Errorf("Expected \"x\", got: %s (%v)", data, err)
+	}
+}
+
+func TestParseID_Float64(t *testing.T) {
+	// encoding/json decodes numeric IDs into float64 when the target is interface{}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(`3`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := ParseID(decoded)
+	if err != nil {
+		t.Fatalf("Failed to parse ID: %v", err)
+	}
+	if id != NewNumberID(3) {
+		t.Errorf("Expected numeric ID 3, got: %v", id)
+	}
+}
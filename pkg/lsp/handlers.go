@@ -0,0 +1,54 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ConfigurationParams represents parameters for workspace/configuration
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+// ConfigurationItem represents a single configuration section request
+type ConfigurationItem struct {
+	ScopeURI string `json:"scopeUri,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
+
+// defaultRequestHandlers returns the handlers used for server-to-client
+// requests that the client has not overridden. They acknowledge requests
+// that need no action so the server never stalls waiting for a reply.
+func defaultRequestHandlers() map[string]RequestHandler {
+	acknowledge := func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, nil
+	}
+
+	return map[string]RequestHandler{
+		"workspace/configuration":        defaultConfiguration,
+		"client/registerCapability":      acknowledge,
+		"client/unregisterCapability":    acknowledge,
+		"window/workDoneProgress/create": acknowledge,
+		"window/showMessageRequest":      acknowledge,
+		"window/showDocument": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return map[string]bool{"success": false}, nil
+		},
+		"workspace/applyEdit": func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{
+				"applied":       false,
+				"failureReason": "client does not apply workspace edits",
+			}, nil
+		},
+	}
+}
+
+// defaultConfiguration answers workspace/configuration with null for every
+// requested item, meaning the client has no settings for it
+func defaultConfiguration(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p ConfigurationParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	return make([]interface{}, len(p.Items)), nil
+}
@@ -0,0 +1,232 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSON-RPC error codes used by the LSP client
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// ID represents a JSON-RPC request ID. JSON-RPC allows both numbers and
+// strings, so the two forms are kept distinct and the value stays comparable
+// for use as a map key.
+type ID struct {
+	Num      int64
+	Str      string
+	IsString bool
+}
+
+// NewNumberID creates a numeric request ID
+func NewNumberID(n int64) ID {
+	return ID{Num: n}
+}
+
+// NewStringID creates a string request ID
+func NewStringID(s string) ID {
+	return ID{Str: s, IsString: true}
+}
+
+// ParseID normalizes a request ID held in an interface{} value, such as the
+// float64 produced by encoding/json, into an ID
+func ParseID(v interface{}) (ID, error) {
+	switch id := v.(type) {
+	case ID:
+		return id, nil
+	case *ID:
+		if id == nil {
+			return ID{}, errors.New("nil request ID")
+		}
+		return *id, nil
+	case string:
+		return NewStringID(id), nil
+	case int:
+		return NewNumberID(int64(id)), nil
+	case int32:
+		return NewNumberID(int64(id)), nil
+	case int64:
+		return NewNumberID(id), nil
+	case float64:
+		if id != math.Trunc(id) || math.IsInf(id, 0) {
+			return ID{}, fmt.Errorf("non-integer request ID: %v", id)
+		}
+		return NewNumberID(int64(id)), nil
+	case json.Number:
+		n, err := id.Int64()
+		if err != nil {
+			return ID{}, fmt.Errorf("invalid numeric request ID: %w", err)
+		}
+		return NewNumberID(n), nil
+	default:
+		return ID{}, fmt.Errorf("unsupported request ID type %T", v)
+	}
+}
+
+// String returns a printable form of the ID
+func (id ID) String() string {
+	if id.IsString {
+		return strconv.Quote(id.Str)
+	}
+	return strconv.FormatInt(id.Num, 10)
+}
+
+// MarshalJSON encodes the ID as a JSON number or string
+func (id ID) MarshalJSON() ([]byte, error) {
+	if id.IsString {
+		return json.Marshal(id.Str)
+	}
+	return []byte(strconv.FormatInt(id.Num, 10)), nil
+}
+
+// UnmarshalJSON decodes a JSON number or string into the ID
+func (id *ID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = NewStringID(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid request ID %s: %w", data, err)
+	}
+	parsed, err := ParseID(n)
+	if err != nil {
+		// Accept integral values written with a fraction or exponent, e.g. 1.0
+		f, ferr := n.Float64()
+		if ferr != nil {
+			return err
+		}
+		if parsed, err = ParseID(f); err != nil {
+			return err
+		}
+	}
+	*id = parsed
+	return nil
+}
+
+// Error implements the error interface so JSON-RPC errors can be returned
+// directly from request handlers
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// message is the wire form of any JSON-RPC message. Requests carry a method
+// and an ID, notifications only a method, and responses only an ID.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *ID             `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+func (m *message) isRequest() bool {
+	return m.Method != "" && m.ID != nil
+}
+
+func (m *message) isNotification() bool {
+	return m.Method != "" && m.ID == nil
+}
+
+func (m *message) isResponse() bool {
+	return m.Method == ""
+}
+
+// UnmarshalResult decodes the response result into v. A missing or null
+// result leaves v untouched.
+func (r *Response) UnmarshalResult(v interface{}) error {
+	var data []byte
+	switch result := r.Result.(type) {
+	case nil:
+		return nil
+	case json.RawMessage:
+		data = result
+	case []byte:
+		data = result
+	default:
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to re-encode result: %w", err)
+		}
+		data = encoded
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ReadMessage reads a single Content-Length framed message body
+func ReadMessage(reader *bufio.Reader) ([]byte, error) {
+	length := -1
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if length < 0 {
+				// Tolerate stray blank lines between messages
+				continue
+			}
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header line: %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length: %q", value)
+			}
+		}
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteMessage marshals message and writes it with a Content-Length header
+func WriteMessage(w io.Writer, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n", len(data))
+	buf.Write(data)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
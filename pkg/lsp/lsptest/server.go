@@ -0,0 +1,231 @@
+// Package lsptest provides a scripted in-process language server for testing
+// code built on the lsp package.
+package lsptest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp"
+)
+
+// HandlerFunc answers a request or observes a notification sent by the
+// client. The returned value is used as the result of a request; returning
+// an *lsp.Error sends that error, any other error becomes an internal error.
+type HandlerFunc func(params json.RawMessage) (interface{}, error)
+
+// Message is a message received from the client
+type Message struct {
+	ID     *lsp.ID         `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *lsp.Error      `json:"error,omitempty"`
+}
+
+// Server is a fake language server connected to an lsp.Client via pipes.
+// Requests from the client are answered by registered handlers, each on its
+// own goroutine so that tests can respond out of order.
+type Server struct {
+	mu       sync.Mutex
+	writeMu  sync.Mutex
+	handlers map[string]HandlerFunc
+	received []Message
+	calls    map[lsp.ID]chan Message
+	nextID   int64
+	notify   chan struct{}
+
+	reader *io.PipeReader
+	writer *io.PipeWriter
+	client *lsp.Client
+}
+
+// NewServer creates a fake server and an lsp.Client connected to it
+func NewServer() *Server {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	s := &Server{
+		handlers: make(map[string]HandlerFunc),
+		calls:    make(map[lsp.ID]chan Message),
+		notify:   make(chan struct{}),
+		reader:   serverReader,
+		writer:   serverWriter,
+	}
+	s.client = lsp.NewClientFromStreams(clientReader, clientWriter)
+
+	go s.serve()
+
+	return s
+}
+
+// Client returns the lsp.Client connected to the server
+func (s *Server) Client() *lsp.Client {
+	return s.client
+}
+
+// Handle registers the handler for a method
+func (s *Server) Handle(method string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// Received returns the messages with the given method received so far
+func (s *Server) Received(method string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []Message
+	for _, msg := range s.received {
+		if msg.Method == method {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// WaitFor blocks until at least n messages with the given method have been
+// received, or done is closed
+func (s *Server) WaitFor(method string, n int, done <-chan struct{}) []Message {
+	for {
+		s.mu.Lock()
+		notify := s.notify
+		s.mu.Unlock()
+
+		if messages := s.Received(method); len(messages) >= n {
+			return messages
+		}
+
+		select {
+		case <-notify:
+		case <-done:
+			return s.Received(method)
+		}
+	}
+}
+
+// Notify sends a notification to the client
+func (s *Server) Notify(method string, params interface{}) error {
+	return s.Send(lsp.Notification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// Call sends a request to the client and waits for its response
+func (s *Server) Call(method string, params interface{}) (Message, error) {
+	s.mu.Lock()
+	s.nextID++
+	id := lsp.NewStringID(fmt.Sprintf("server-%d", s.nextID))
+	ch := make(chan Message, 1)
+	s.calls[id] = ch
+	s.mu.Unlock()
+
+	err := s.Send(lsp.Request{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg, ok := <-ch
+	if !ok {
+		return Message{}, errors.New("connection closed")
+	}
+	return msg, nil
+}
+
+// Send writes an arbitrary message to the client
+func (s *Server) Send(message interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return lsp.WriteMessage(s.writer, message)
+}
+
+// Close shuts down the server and the connected client
+func (s *Server) Close() error {
+	s.client.Close()
+	s.reader.Close()
+	return s.writer.Close()
+}
+
+func (s *Server) serve() {
+	reader := bufio.NewReader(s.reader)
+
+	for {
+		data, err := lsp.ReadMessage(reader)
+		if err != nil {
+			break
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		s.received = append(s.received, msg)
+		close(s.notify)
+		s.notify = make(chan struct{})
+		handler := s.handlers[msg.Method]
+		var call chan Message
+		if msg.Method == "" && msg.ID != nil {
+			call = s.calls[*msg.ID]
+			delete(s.calls, *msg.ID)
+		}
+		s.mu.Unlock()
+
+		switch {
+		case call != nil:
+			call <- msg
+		case msg.ID != nil && msg.Method != "":
+			go s.respond(*msg.ID, msg.Method, msg.Params, handler)
+		case handler != nil:
+			handler(msg.Params)
+		}
+	}
+
+	s.mu.Lock()
+	for id, ch := range s.calls {
+		close(ch)
+		delete(s.calls, id)
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) respond(id lsp.ID, method string, params json.RawMessage, handler HandlerFunc) {
+	response := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+
+	if handler == nil {
+		response["error"] = &lsp.Error{
+			Code:    lsp.CodeMethodNotFound,
+			Message: fmt.Sprintf("method not found: %s", method),
+		}
+		s.Send(response)
+		return
+	}
+
+	result, err := handler(params)
+	if err != nil {
+		var rpcErr *lsp.Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &lsp.Error{Code: lsp.CodeInternalError, Message: err.Error()}
+		}
+		response["error"] = rpcErr
+	} else {
+		response["result"] = result
+	}
+	s.Send(response)
+}
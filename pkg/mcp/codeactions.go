@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+func codeActionsTool() Tool {
+	properties := rangeProperties()
+	properties["kind"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Only return actions of this kind or its sub-kinds, for example quickfix or source.formatAll.terraform",
+	}
+	properties["action"] = map[string]interface{}{
+		"type":        "integer",
+		"description": "Number of an action from the list (1-based) to resolve and preview as a diff",
+	}
+	properties["apply"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Write the changes of the selected action to disk and run its command, if any",
+	}
+
+	return Tool{
+		Name:        "terraform_code_actions",
+		Description: "List the code actions terraform-ls offers for a range, such as quick fixes and formatting, or resolve and apply one of them. Without a range the whole file is used",
+		InputSchema: documentToolSchema("Path to the specific Terraform file", properties),
+	}
+}
+
+func (s *Server) handleCodeActionsTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	r, err := rangeArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	var only []string
+	if kind, ok := args["kind"].(string); ok && kind != "" {
+		only = []string{kind}
+	}
+
+	selected := 0
+	if value, ok := args["action"].(float64); ok {
+		selected = int(value)
+		if selected < 1 {
+			return s.errorResponse(requestID, -32602, "action must be at least 1")
+		}
+	}
+	apply, _ := args["apply"].(bool)
+	if apply && selected == 0 {
+		return s.errorResponse(requestID, -32602, "apply requires action")
+	}
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	if r == nil {
+		r = &terraform.Range{End: terraform.PositionAt(doc.content, len(doc.content))}
+	}
+
+	actions, err := doc.client.CodeActions(ctx, doc.uri, doc.content, *r, only)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get code actions: %v", err))
+	}
+
+	if selected == 0 {
+		return textResult(requestID, formatCodeActions(doc.filePath, actions))
+	}
+	if selected > len(actions) {
+		return s.errorResponse(requestID, -32602, fmt.Sprintf("action %d does not exist; %d action(s) available", selected, len(actions)))
+	}
+
+	action, err := doc.client.ResolveCodeAction(ctx, actions[selected-1])
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to resolve code action: %v", err))
+	}
+	if action.Disabled != nil {
+		return s.errorResponse(requestID, -32602, fmt.Sprintf("Code action %q is disabled: %s", action.Title, action.Disabled.Reason))
+	}
+
+	var changes []terraform.FileChange
+	if action.Edit != nil {
+		changes, err = doc.client.ResolveWorkspaceEdit(*action.Edit)
+		if err != nil {
+			return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to resolve code action edits: %v", err))
+		}
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Code action %q", action.Title)
+	if !apply {
+		summary.WriteString(" (dry run, no files written).")
+		if action.Command != nil {
+			fmt.Fprintf(&summary, " Applying it also runs the command %s.", action.Command.Command)
+		}
+		return textResult(requestID, formatFileChanges(summary.String(), changes))
+	}
+
+	if err := doc.client.ApplyFileChanges(ctx, changes, doc.uri); err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to apply code action: %v", err))
+	}
+	summary.WriteString(" applied.")
+
+	if action.Command != nil {
+		if _, err := doc.client.ExecuteCommand(ctx, action.Command.Command, action.Command.Arguments...); err != nil {
+			return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to run command %s: %v", action.Command.Command, err))
+		}
+		fmt.Fprintf(&summary, " Ran the command %s.", action.Command.Command)
+	}
+
+	return textResult(requestID, formatFileChanges(summary.String(), changes))
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+func TestServer_CodeActionsToolApplies(t *testing.T) {
+	server, fake := newFakeServer(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	content := "locals {\n\tfoo=1\n}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	uri := terraform.PathToURI(path)
+
+	fake.Handle("textDocument/codeAction", func(params json.RawMessage) (interface{}, error) {
+		return []terraform.CodeAction{{
+			Title: "Format document",
+			Kind:  "source.formatAll.terraform",
+			Edit: &terraform.WorkspaceEdit{
+				Changes: map[string][]terraform.TextEdit{
+					uri: {{Range: terraform.Range{Start: terraform.Position{Line: 1}, End: terraform.Position{Line: 1, Character: 6}}, NewText: "  foo = 1"}},
+				},
+			},
+		}}, nil
+	})
+
+	result := callTool(t, server, "terraform_code_actions", map[string]interface{}{
+		"workspace_path": dir,
+		"file_path":      path,
+		"content":        content,
+		"action":         1,
+		"apply":          true,
+	})
+	if !strings.Contains(result.Content[0].Text, `Code action "Format document"`) {
+		t.Errorf("Expected the applied action in the summary, got: %s", result.Content[0].Text)
+	}
+
+	want := "locals {\n  foo = 1\n}\n"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != want {
+		t.Errorf("Expected %q, got: %q", want, data)
+	}
+
+	params := waitForDidChange(t, fake, 1)
+	if params.TextDocument.URI != uri || params.ContentChanges[0].Text != want {
+		t.Errorf("Expected main.tf to be synced with the applied content, got: %+v", params)
+	}
+}
@@ -0,0 +1,29 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+func documentLinksTool() Tool {
+	return Tool{
+		Name:        "terraform_document_links",
+		Description: "List every resource, data source and module block in a Terraform file with the documentation URL terraform-ls links it to, such as the Terraform Registry page of a resource type or module",
+		InputSchema: documentToolSchema("Path to the specific Terraform file", nil),
+	}
+}
+
+func (s *Server) handleDocumentLinksTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	blocks, err := doc.client.BlockLinks(ctx, doc.uri, doc.content)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get document links: %v", err))
+	}
+
+	return structuredResult(requestID, map[string]interface{}{"blocks": blocks}, formatBlockLinks(doc.filePath, blocks))
+}
@@ -0,0 +1,34 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+func enclosingRangesTool() Tool {
+	return Tool{
+		Name:        "terraform_enclosing_ranges",
+		Description: "Get the exact boundaries of the block, attribute and expression at a position, with their text. Enclosing blocks are listed innermost first, ending with the top-level block, so that a single resource block can be replaced safely. Ranges are 1-based line:col with an exclusive end",
+		InputSchema: documentToolSchema("Path to the specific Terraform file", positionProperties(), "line", "character"),
+	}
+}
+
+func (s *Server) handleEnclosingRangesTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	line, character, err := positionArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	ranges, err := doc.client.EnclosingRanges(ctx, doc.uri, doc.content, line, character)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get enclosing ranges: %v", err))
+	}
+
+	return structuredResult(requestID, ranges, formatEnclosingRanges(doc.filePath, line, character, ranges))
+}
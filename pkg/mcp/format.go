@@ -0,0 +1,502 @@
+package mcp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+// severityNames maps LSP diagnostic severities to display names
+var severityNames = map[int]string{
+	terraform.SeverityError:       "error",
+	terraform.SeverityWarning:     "warning",
+	terraform.SeverityInformation: "info",
+	terraform.SeverityHint:        "hint",
+}
+
+// formatValidationResult renders diagnostics as compiler-style lines with
+// 1-based line and column numbers
+func formatValidationResult(filePath string, result *terraform.ValidationResult) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Validation completed for %s. Found %d diagnostic(s).", filePath, len(result.Diagnostics))
+	if result.TimedOut {
+		b.WriteString(" terraform-ls did not publish diagnostics in time; results may be incomplete.")
+	}
+	writeDiagnostics(&b, filePath, result.Diagnostics)
+
+	related := make([]string, 0, len(result.RelatedDocuments))
+	for uri := range result.RelatedDocuments {
+		related = append(related, uri)
+	}
+	sort.Strings(related)
+
+	for _, uri := range related {
+		writeDiagnostics(&b, terraform.URIToPath(uri), result.RelatedDocuments[uri])
+	}
+
+	return b.String()
+}
+
+func writeDiagnostics(b *strings.Builder, path string, diagnostics []terraform.Diagnostic) {
+	for _, d := range diagnostics {
+		severity, ok := severityNames[d.Severity]
+		if !ok {
+			severity = "error"
+		}
+
+		fmt.Fprintf(b, "\n%s:%d:%d: %s: %s", path, d.Range.Start.Line+1, d.Range.Start.Character+1, severity, d.Message)
+		if d.Source != "" {
+			fmt.Fprintf(b, " [%s]", d.Source)
+		}
+		if d.Code != nil {
+			fmt.Fprintf(b, " (%v)", d.Code)
+		}
+		if d.CodeDescription != nil {
+			fmt.Fprintf(b, " <%s>", d.CodeDescription.Href)
+		}
+		for _, info := range d.RelatedInformation {
+			fmt.Fprintf(b, "\n  %s:%d:%d: %s", terraform.URIToPath(info.Location.URI),
+				info.Location.Range.Start.Line+1, info.Location.Range.Start.Character+1, info.Message)
+		}
+	}
+}
+
+// formatCompletionResult renders completion items in ranked order, with the
+// text each one inserts and its documentation
+func formatCompletionResult(filePath string, line, character int, result *terraform.CompletionResult, items []terraform.CompletionItem, keepSnippets bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Completion completed for %s at line %d, character %d. Found %d suggestion(s).", filePath, line, character, len(result.Items))
+	if len(items) < len(result.Items) {
+		fmt.Fprintf(&b, " Showing the first %d.", len(items))
+	}
+	if result.IsIncomplete {
+		b.WriteString(" The list is incomplete; type more characters to narrow it down.")
+	}
+
+	for i, item := range items {
+		fmt.Fprintf(&b, "\n\n%d. %s", i+1, item.Label)
+		if kind := terraform.CompletionItemKindName(item.Kind); kind != "" {
+			fmt.Fprintf(&b, " (%s)", kind)
+		}
+		if item.Deprecated {
+			b.WriteString(" [deprecated]")
+		}
+		if item.Detail != "" {
+			fmt.Fprintf(&b, "\n   Detail: %s", item.Detail)
+		}
+		if text := item.InsertionText(keepSnippets); text != item.Label {
+			fmt.Fprintf(&b, "\n   Insert: %s", strings.ReplaceAll(text, "\n", "\n           "))
+		}
+		if item.Documentation != "" {
+			fmt.Fprintf(&b, "\n   %s", strings.ReplaceAll(strings.TrimSpace(item.Documentation), "\n", "\n   "))
+		}
+	}
+
+	return b.String()
+}
+
+// formatLocations renders locations as 1-based path:line:col ranges, each
+// followed by a snippet of the surrounding source
+func formatLocations(summary string, locations []terraform.LocationResult) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s Found %d location(s).", summary, len(locations))
+	for _, loc := range locations {
+		fmt.Fprintf(&b, "\n\n%s:%d:%d-%d:%d", loc.Path, loc.Range.Start.Line+1, loc.Range.Start.Character+1,
+			loc.Range.End.Line+1, loc.Range.End.Character+1)
+		if loc.Snippet != "" {
+			fmt.Fprintf(&b, "\n%s", strings.TrimSuffix(loc.Snippet, "\n"))
+		}
+	}
+
+	return b.String()
+}
+
+// formatDocumentSymbols renders the outline of a file
+func formatDocumentSymbols(filePath string, symbols []terraform.DocumentSymbol, depth int) string {
+	return fmt.Sprintf("Symbols in %s. Found %d top-level symbol(s).", filePath, len(symbols)) +
+		writeSymbolOutline(symbols, depth)
+}
+
+// formatWorkspaceSymbols renders workspace symbols as one outline per file,
+// with paths relative to the workspace where possible
+func formatWorkspaceSymbols(workspacePath, query string, symbols []terraform.SymbolInformation, depth int) string {
+	var files []string
+	byFile := make(map[string][]terraform.SymbolInformation)
+	for _, symbol := range symbols {
+		uri := symbol.Location.URI
+		if _, exists := byFile[uri]; !exists {
+			files = append(files, uri)
+		}
+		byFile[uri] = append(byFile[uri], symbol)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Workspace symbols in %s", workspacePath)
+	if query != "" {
+		fmt.Fprintf(&b, " matching %q", query)
+	}
+	fmt.Fprintf(&b, ". Found %d symbol(s) in %d file(s).", len(symbols), len(files))
+
+	absWorkspace, _ := filepath.Abs(workspacePath)
+	for _, uri := range files {
+		path := terraform.URIToPath(uri)
+		if rel, err := filepath.Rel(absWorkspace, path); err == nil && filepath.IsLocal(rel) {
+			path = rel
+		}
+		fmt.Fprintf(&b, "\n\n%s%s", path, writeSymbolOutline(terraform.NestSymbols(byFile[uri]), depth))
+	}
+
+	return b.String()
+}
+
+// writeSymbolOutline renders symbols as an indented list, one per line, down
+// to depth levels. Ranges are 1-based line:col pairs.
+func writeSymbolOutline(symbols []terraform.DocumentSymbol, depth int) string {
+	var b strings.Builder
+
+	var write func(symbols []terraform.DocumentSymbol, level int)
+	write = func(symbols []terraform.DocumentSymbol, level int) {
+		for _, symbol := range symbols {
+			fmt.Fprintf(&b, "\n%s- %s", strings.Repeat("  ", level), symbol.Name)
+			if kind := terraform.SymbolKindName(symbol.Kind); kind != "" {
+				fmt.Fprintf(&b, " (%s)", kind)
+			}
+			if symbol.Detail != "" {
+				fmt.Fprintf(&b, " %s", symbol.Detail)
+			}
+			if symbol.Deprecated {
+				b.WriteString(" [deprecated]")
+			}
+			fmt.Fprintf(&b, " %d:%d-%d:%d", symbol.Range.Start.Line+1, symbol.Range.Start.Character+1,
+				symbol.Range.End.Line+1, symbol.Range.End.Character+1)
+
+			if len(symbol.Children) == 0 {
+				continue
+			}
+			if level+1 < depth {
+				write(symbol.Children, level+1)
+			} else {
+				fmt.Fprintf(&b, " (%d nested)", len(symbol.Children))
+			}
+		}
+	}
+	write(symbols, 0)
+
+	return b.String()
+}
+
+// formatFileChanges renders the edits of a workspace edit as one unified
+// diff per file
+func formatFileChanges(summary string, changes []terraform.FileChange) string {
+	var b strings.Builder
+
+	edits := 0
+	for _, change := range changes {
+		edits += change.Edits
+	}
+	fmt.Fprintf(&b, "%s %d edit(s) in %d file(s).", summary, edits, len(changes))
+
+	for _, change := range changes {
+		if diff := terraform.UnifiedDiff(change.Path, change.Path, change.Original, change.Content); diff != "" {
+			fmt.Fprintf(&b, "\n\n%s", strings.TrimSuffix(diff, "\n"))
+		}
+	}
+
+	return b.String()
+}
+
+// formatCodeActions renders the available code actions as a numbered list,
+// the numbers being what the terraform_code_actions tool accepts as action
+func formatCodeActions(filePath string, actions []terraform.CodeAction) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Code actions for %s. Found %d action(s).", filePath, len(actions))
+	for i, action := range actions {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, action.Title)
+		if action.Kind != "" {
+			fmt.Fprintf(&b, " (%s)", action.Kind)
+		}
+		if action.IsPreferred {
+			b.WriteString(" [preferred]")
+		}
+		if action.Disabled != nil {
+			fmt.Fprintf(&b, " [disabled: %s]", action.Disabled.Reason)
+		}
+		for _, d := range action.Diagnostics {
+			fmt.Fprintf(&b, "\n   fixes %d:%d: %s", d.Range.Start.Line+1, d.Range.Start.Character+1, d.Message)
+		}
+	}
+
+	return b.String()
+}
+
+// formatModuleCalls renders the module call tree of a module and the modules
+// that call it
+func formatModuleCalls(modulePath string, calls *terraform.ModuleCallsResult, callers *terraform.ModuleCallersResult) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Module calls of %s. Found %d module call(s).", modulePath, len(calls.ModuleCalls))
+
+	var write func(calls []terraform.ModuleCall, level int)
+	write = func(calls []terraform.ModuleCall, level int) {
+		for _, call := range calls {
+			fmt.Fprintf(&b, "\n%s- %s: %s", strings.Repeat("  ", level), call.Name, call.SourceAddr)
+			if call.Version != "" {
+				fmt.Fprintf(&b, " (version %s)", call.Version)
+			}
+			if call.SourceType != "" {
+				fmt.Fprintf(&b, " [%s]", call.SourceType)
+			}
+			if call.DocsLink != "" {
+				fmt.Fprintf(&b, " <%s>", call.DocsLink)
+			}
+			write(call.DependentModules, level+1)
+		}
+	}
+	write(calls.ModuleCalls, 0)
+
+	fmt.Fprintf(&b, "\n\nCalled by %d module(s).", len(callers.Callers))
+	for _, caller := range callers.Callers {
+		fmt.Fprintf(&b, "\n- %s", terraform.URIToPath(caller.URI))
+	}
+
+	return b.String()
+}
+
+// formatModuleProviders renders the provider requirements of a module next
+// to the installed versions, followed by the Terraform version
+func formatModuleProviders(modulePath string, providers *terraform.ModuleProvidersResult, version *terraform.ModuleTerraformResult) string {
+	var b strings.Builder
+
+	addresses := make([]string, 0, len(providers.ProviderRequirements))
+	for address := range providers.ProviderRequirements {
+		addresses = append(addresses, address)
+	}
+	for address := range providers.InstalledProviders {
+		if _, required := providers.ProviderRequirements[address]; !required {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+
+	fmt.Fprintf(&b, "Providers of %s. Found %d provider(s).", modulePath, len(addresses))
+	for _, address := range addresses {
+		requirement, required := providers.ProviderRequirements[address]
+		name := requirement.DisplayName
+		if name == "" {
+			name = address
+		}
+
+		fmt.Fprintf(&b, "\n- %s (%s)", name, address)
+		if !required {
+			b.WriteString("\n  required: not declared")
+		} else if requirement.VersionConstraint != "" {
+			fmt.Fprintf(&b, "\n  required: %s", requirement.VersionConstraint)
+		} else {
+			b.WriteString("\n  required: any version")
+		}
+		if installed, ok := providers.InstalledProviders[address]; ok {
+			fmt.Fprintf(&b, "\n  installed: %s", installed)
+		} else {
+			b.WriteString("\n  installed: not installed (run terraform init)")
+		}
+		if requirement.DocsLink != "" {
+			fmt.Fprintf(&b, "\n  docs: %s", requirement.DocsLink)
+		}
+	}
+
+	b.WriteString("\n\nTerraform")
+	if version.RequiredVersion != "" {
+		fmt.Fprintf(&b, "\n  required: %s", version.RequiredVersion)
+	} else {
+		b.WriteString("\n  required: any version")
+	}
+	if version.DiscoveredVersion != "" {
+		fmt.Fprintf(&b, "\n  installed: %s", version.DiscoveredVersion)
+	} else {
+		b.WriteString("\n  installed: not found")
+	}
+
+	return b.String()
+}
+
+// formatModuleValidation renders the diagnostics of terraform validate for
+// every file of a module
+func formatModuleValidation(modulePath string, result *terraform.ModuleValidationResult) string {
+	var b strings.Builder
+
+	uris := make([]string, 0, len(result.Diagnostics))
+	count := 0
+	for uri, diagnostics := range result.Diagnostics {
+		uris = append(uris, uri)
+		count += len(diagnostics)
+	}
+	sort.Strings(uris)
+
+	fmt.Fprintf(&b, "terraform validate completed for %s. Found %d diagnostic(s).", modulePath, count)
+	if result.TimedOut {
+		b.WriteString(" terraform-ls did not publish diagnostics in time; results may be incomplete.")
+	}
+	for _, uri := range uris {
+		writeDiagnostics(&b, terraform.URIToPath(uri), result.Diagnostics[uri])
+	}
+
+	return b.String()
+}
+
+// formatSemanticTokens renders one token per line as a 1-based line:col
+// position followed by the type, modifiers and quoted text
+func formatSemanticTokens(filePath string, tokens []terraform.SemanticToken) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Semantic tokens for %s. Found %d token(s).", filePath, len(tokens))
+	for _, token := range tokens {
+		fmt.Fprintf(&b, "\n%d:%d %s", token.Line+1, token.Character+1, token.Type)
+		if len(token.Modifiers) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(token.Modifiers, ","))
+		}
+		fmt.Fprintf(&b, " %q", token.Text)
+	}
+
+	return b.String()
+}
+
+// formatSignatureHelp renders the active signature with its parameters,
+// marking the parameter at the cursor with '>'
+func formatSignatureHelp(help *terraform.SignatureHelp) string {
+	var b strings.Builder
+
+	signature, active := help.Active()
+	b.WriteString(signature.Label)
+	if active >= 0 {
+		fmt.Fprintf(&b, "\n\nActive parameter: %s (%d of %d)", signature.Parameters[active].Label, active+1, len(signature.Parameters))
+	}
+
+	if len(signature.Parameters) > 0 {
+		b.WriteString("\n\nParameters:")
+	}
+	for i, param := range signature.Parameters {
+		marker := " "
+		if i == active {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "\n%s %d. %s", marker, i+1, param.Label)
+		if param.Documentation != "" {
+			fmt.Fprintf(&b, ": %s", strings.ReplaceAll(strings.TrimSpace(param.Documentation), "\n", "\n     "))
+		}
+	}
+
+	if signature.Documentation != "" {
+		fmt.Fprintf(&b, "\n\n%s", strings.TrimSpace(signature.Documentation))
+	}
+
+	if len(help.Signatures) > 1 {
+		b.WriteString("\n\nOther signatures:")
+		for _, other := range help.Signatures {
+			if other.Label != signature.Label {
+				fmt.Fprintf(&b, "\n- %s", other.Label)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// formatReferenceCounts renders one declaration per line with its location
+// and reference count, flagging unreferenced ones
+func formatReferenceCounts(modulePath string, counts []terraform.ReferenceCount, unreferencedOnly bool) string {
+	var b strings.Builder
+
+	unreferenced := 0
+	for _, count := range counts {
+		if count.References == 0 {
+			unreferenced++
+		}
+	}
+
+	fmt.Fprintf(&b, "Reference counts for %s. Found %d declaration(s), %d unreferenced.", modulePath, len(counts), unreferenced)
+	for _, count := range counts {
+		if unreferencedOnly && count.References > 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n%s:%d:%d: %s %s: %d reference(s)", filepath.Base(terraform.URIToPath(count.URI)),
+			count.Range.Start.Line+1, count.Range.Start.Character+1, count.Kind, count.Name, count.References)
+		if count.References == 0 {
+			b.WriteString(" [unreferenced]")
+		}
+	}
+
+	return b.String()
+}
+
+// formatEnclosingRanges renders the blocks, attribute and expression around
+// a position, each with its 1-based range followed by its text
+func formatEnclosingRanges(filePath string, line, character int, ranges *terraform.EnclosingRanges) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Enclosing ranges for %s:%d:%d. Found %d block(s).", filePath, line+1, character+1, len(ranges.Blocks))
+
+	write := func(label string, r terraform.TextRange) {
+		fmt.Fprintf(&b, "\n\n%s %d:%d-%d:%d\n%s", label, r.Range.Start.Line+1, r.Range.Start.Character+1,
+			r.Range.End.Line+1, r.Range.End.Character+1, r.Text)
+	}
+	if ranges.Expression != nil {
+		write("Expression", *ranges.Expression)
+	}
+	if ranges.Attribute != nil {
+		write("Attribute", *ranges.Attribute)
+	}
+	for i, block := range ranges.Blocks {
+		label := "Block"
+		switch {
+		case i == len(ranges.Blocks)-1:
+			label = "Top-level block"
+		case i > 0:
+			label = "Parent block"
+		}
+		write(label, block)
+	}
+
+	return b.String()
+}
+
+// formatBlockLinks renders one block per line with its 1-based position and
+// documentation URL
+func formatBlockLinks(filePath string, blocks []terraform.BlockLink) string {
+	var b strings.Builder
+
+	linked := 0
+	for _, block := range blocks {
+		if block.URL != "" {
+			linked++
+		}
+	}
+
+	fmt.Fprintf(&b, "Documentation links for %s. Found %d block(s), %d with a link.", filePath, len(blocks), linked)
+	for _, block := range blocks {
+		fmt.Fprintf(&b, "\n%d:%d %s ", block.Range.Start.Line+1, block.Range.Start.Character+1, block.Kind)
+		if block.Type != "" {
+			fmt.Fprintf(&b, "%s.%s", block.Type, block.Name)
+		} else {
+			b.WriteString(block.Name)
+		}
+		if block.Source != "" {
+			fmt.Fprintf(&b, " (%s)", block.Source)
+		}
+		if block.URL != "" {
+			fmt.Fprintf(&b, ": %s", block.URL)
+		} else {
+			b.WriteString(": no documentation link")
+		}
+	}
+
+	return b.String()
+}
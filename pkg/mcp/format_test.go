@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+func TestFormatValidationResult(t *testing.T) {
+	result := &terraform.ValidationResult{
+		URI: "file:///workspace/main.tf",
+		Diagnostics: []terraform.Diagnostic{
+			{
+				Range:    terraform.Range{Start: terraform.Position{Line: 1, Character: 2}},
+				Severity: terraform.SeverityWarning,
+				Source:   "terraform",
+				Message:  "Deprecated attribute",
+			},
+		},
+		RelatedDocuments: map[string][]terraform.Diagnostic{
+			"file:///workspace/outputs.tf": {
+				{Severity: terraform.SeverityError, Message: "Reference to undeclared resource"},
+			},
+		},
+	}
+
+	text := formatValidationResult("/workspace/main.tf", result)
+
+	expected := []string{
+		"Found 1 diagnostic(s).",
+		"/workspace/main.tf:2:3: warning: Deprecated attribute [terraform]",
+		"/workspace/outputs.tf:1:1: error: Reference to undeclared resource",
+	}
+	for _, want := range expected {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, text)
+		}
+	}
+}
+
+func TestFormatDocumentSymbols(t *testing.T) {
+	symbols := []terraform.DocumentSymbol{
+		{
+			Name:  "locals",
+			Kind:  5,
+			Range: terraform.Range{End: terraform.Position{Line: 3, Character: 1}},
+			Children: []terraform.DocumentSymbol{
+				{
+					Name:     "tags",
+					Kind:     19,
+					Range:    terraform.Range{Start: terraform.Position{Line: 1, Character: 2}, End: terraform.Position{Line: 2, Character: 3}},
+					Children: []terraform.DocumentSymbol{{Name: "env", Kind: 15}},
+				},
+			},
+		},
+	}
+
+	text := formatDocumentSymbols("/workspace/main.tf", symbols, 2)
+
+	expected := []string{
+		"Found 1 top-level symbol(s).",
+		"\n- locals (class) 1:1-4:2",
+		"\n  - tags (object) 2:3-3:4 (1 nested)",
+	}
+	for _, want := range expected {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "env") {
+		t.Errorf("Expected symbols below the depth to be omitted, got:\n%s", text)
+	}
+}
+
+func TestFormatModuleProviders(t *testing.T) {
+	providers := &terraform.ModuleProvidersResult{
+		ProviderRequirements: map[string]terraform.ProviderRequirement{
+			"registry.terraform.io/hashicorp/aws": {DisplayName: "hashicorp/aws", VersionConstraint: "~> 5.0"},
+		},
+		InstalledProviders: map[string]string{
+			"registry.terraform.io/hashicorp/aws":    "5.31.0",
+			"registry.terraform.io/hashicorp/random": "3.6.0",
+		},
+	}
+	version := &terraform.ModuleTerraformResult{RequiredVersion: ">= 1.5"}
+
+	text := formatModuleProviders("/workspace", providers, version)
+
+	expected := []string{
+		"Found 2 provider(s).",
+		"- hashicorp/aws (registry.terraform.io/hashicorp/aws)\n  required: ~> 5.0\n  installed: 5.31.0",
+		"- registry.terraform.io/hashicorp/random (registry.terraform.io/hashicorp/random)\n  required: not declared\n  installed: 3.6.0",
+		"Terraform\n  required: >= 1.5\n  installed: not found",
+	}
+	for _, want := range expected {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, text)
+		}
+	}
+}
+
+func TestFormatEnclosingRanges(t *testing.T) {
+	ranges := &terraform.EnclosingRanges{
+		Blocks: []terraform.TextRange{{
+			Range: terraform.Range{End: terraform.Position{Line: 2, Character: 1}},
+			Text:  "locals {\n  a = 1\n}",
+		}},
+		Attribute: &terraform.TextRange{
+			Range: terraform.Range{Start: terraform.Position{Line: 1, Character: 2}, End: terraform.Position{Line: 1, Character: 7}},
+			Text:  "a = 1",
+		},
+		Expression: &terraform.TextRange{
+			Range: terraform.Range{Start: terraform.Position{Line: 1, Character: 6}, End: terraform.Position{Line: 1, Character: 7}},
+			Text:  "1",
+		},
+	}
+
+	want := "Enclosing ranges for main.tf:2:7. Found 1 block(s).\n\n" +
+		"Expression 2:7-2:8\n1\n\n" +
+		"Attribute 2:3-2:8\na = 1\n\n" +
+		"Top-level block 1:1-3:2\nlocals {\n  a = 1\n}"
+	if got := formatEnclosingRanges("main.tf", 1, 6, ranges); got != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatBlockLinks(t *testing.T) {
+	blocks := []terraform.BlockLink{
+		{Kind: "resource", Type: "aws_instance", Name: "web", URL: "https://example.com/instance"},
+		{Kind: "module", Name: "vpc", Source: "./vpc", Range: terraform.Range{Start: terraform.Position{Line: 4}}},
+	}
+
+	want := "Documentation links for main.tf. Found 2 block(s), 1 with a link.\n" +
+		"1:1 resource aws_instance.web: https://example.com/instance\n" +
+		"5:1 module vpc (./vpc): no documentation link"
+	if got := formatBlockLinks("main.tf", blocks); got != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+func hoverTool() Tool {
+	return Tool{
+		Name:        "terraform_hover",
+		Description: "Show the documentation for the attribute, block, resource type or function at a position, as returned by terraform-ls from its provider schemas without network access",
+		InputSchema: documentToolSchema("Path to the specific Terraform file", positionProperties(), "line", "character"),
+	}
+}
+
+func (s *Server) handleHoverTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	line, character, err := positionArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	result, err := doc.client.Hover(ctx, doc.uri, doc.content, line, character)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get hover: %v", err))
+	}
+
+	if result.Contents == "" {
+		return textResult(requestID, fmt.Sprintf("No documentation available in %s at line %d, character %d.", doc.filePath, line, character))
+	}
+
+	return textResult(requestID, result.Contents)
+}
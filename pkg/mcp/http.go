@@ -0,0 +1,411 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of the Streamable HTTP transport
+const (
+	SessionIDHeader       = "Mcp-Session-Id"
+	ProtocolVersionHeader = "Mcp-Protocol-Version"
+)
+
+const (
+	// maxRequestBytes bounds the size of a POSTed message. Tool calls carry
+	// whole files, so this is generous.
+	maxRequestBytes = 32 << 20
+
+	// defaultKeepAlive is how often an idle SSE stream gets a comment, so
+	// that proxies do not close it
+	defaultKeepAlive = 30 * time.Second
+
+	// DefaultHTTPSessionTimeout is how long a Streamable HTTP session is
+	// kept after its last use. Clients are supposed to end sessions with
+	// DELETE, but many just go away.
+	DefaultHTTPSessionTimeout = 30 * time.Minute
+)
+
+// httpSession is a client of the Streamable HTTP transport. active counts
+// the requests and streams in progress; both it and lastUsed are guarded by
+// the handler's mutex.
+type httpSession struct {
+	session  *Session
+	done     chan struct{}
+	active   int
+	lastUsed time.Time
+}
+
+// StreamableHTTPHandler serves the MCP Streamable HTTP transport on a single
+// endpoint. Clients POST one JSON-RPC message per request and get the
+// response as JSON, or as a server-sent event if they only accept
+// text/event-stream. A GET opens an SSE stream for server messages, and a
+// DELETE ends the session. Each client gets its own Session, identified by
+// the Mcp-Session-Id header the server assigns once initialize succeeds.
+// Sessions unused for IdleTimeout are ended as well.
+type StreamableHTTPHandler struct {
+	server *Server
+
+	// KeepAlive is how often idle SSE streams get a comment
+	KeepAlive time.Duration
+
+	// IdleTimeout is how long a session without requests or open streams
+	// is kept. It must be set before the handler serves requests.
+	IdleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+	reaping  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStreamableHTTPHandler creates a Streamable HTTP handler for server
+func NewStreamableHTTPHandler(server *Server) *StreamableHTTPHandler {
+	return &StreamableHTTPHandler{
+		server:      server,
+		KeepAlive:   defaultKeepAlive,
+		IdleTimeout: DefaultHTTPSessionTimeout,
+		sessions:    make(map[string]*httpSession),
+		stop:        make(chan struct{}),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *StreamableHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowedOrigin(r) {
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	if version := r.Header.Get(ProtocolVersionHeader); version != "" && negotiateProtocolVersion(version) != version {
+		http.Error(w, fmt.Sprintf("Unsupported protocol version: %s", version), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StreamableHTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read request: %v", err), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var request Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeJSON(w, http.StatusBadRequest, Response{
+			JSONRPC: "2.0",
+			Error: &Error{
+				Code:    -32700,
+				Message: "Parse error",
+				Data:    err.Error(),
+			},
+		})
+		return
+	}
+
+	// A new session starts with initialize and is only registered once
+	// that succeeds; every other message must name an existing one
+	var client *httpSession
+	if id := r.Header.Get(SessionIDHeader); id != "" {
+		if client = h.acquire(id); client == nil {
+			http.Error(w, "Unknown session", http.StatusNotFound)
+			return
+		}
+		defer h.release(client)
+	} else if request.Method == "initialize" && !request.IsNotification() {
+		client = newHTTPSession()
+	} else {
+		http.Error(w, fmt.Sprintf("Missing %s header", SessionIDHeader), http.StatusBadRequest)
+		return
+	}
+
+	// Responses to server requests are only acknowledged
+	if request.Method == "" {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	response := h.server.HandleRequest(WithSession(r.Context(), client.session), request)
+	if response == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if r.Header.Get(SessionIDHeader) == "" && response.Error == nil {
+		w.Header().Set(SessionIDHeader, h.register(client))
+	}
+
+	if acceptsJSON(r) {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	writeEvent(w, response)
+}
+
+func (h *StreamableHTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	client, ok := h.requireSession(w, r)
+	if !ok {
+		return
+	}
+	defer h.release(client)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// The server sends no notifications of its own yet, so the stream only
+	// carries keep-alive comments until the client or the session goes away
+	ticker := time.NewTicker(h.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client.done:
+			return
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *StreamableHTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	client, ok := h.requireSession(w, r)
+	if !ok {
+		return
+	}
+	defer h.release(client)
+
+	h.mu.Lock()
+	id := r.Header.Get(SessionIDHeader)
+	if h.sessions[id] == client {
+		close(client.done)
+		delete(h.sessions, id)
+	}
+	h.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireSession returns the session named by the request, or writes an
+// error if there is none. The caller must release the returned session.
+func (h *StreamableHTTPHandler) requireSession(w http.ResponseWriter, r *http.Request) (*httpSession, bool) {
+	id := r.Header.Get(SessionIDHeader)
+	if id == "" {
+		http.Error(w, fmt.Sprintf("Missing %s header", SessionIDHeader), http.StatusBadRequest)
+		return nil, false
+	}
+
+	client := h.acquire(id)
+	if client == nil {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return nil, false
+	}
+	return client, true
+}
+
+// acquire returns the session with the given ID and marks it in use until
+// release is called, or returns nil if there is no such session
+func (h *StreamableHTTPHandler) acquire(id string) *httpSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client := h.sessions[id]
+	if client != nil {
+		client.active++
+		client.lastUsed = time.Now()
+	}
+	return client
+}
+
+func (h *StreamableHTTPHandler) release(client *httpSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client.active--
+	client.lastUsed = time.Now()
+}
+
+func newHTTPSession() *httpSession {
+	return &httpSession{
+		session: NewSession(),
+		done:    make(chan struct{}),
+	}
+}
+
+// register adds an initialized session and returns its ID. The first
+// session starts the reaper of idle sessions.
+func (h *StreamableHTTPHandler) register(client *httpSession) string {
+	id := newSessionID()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client.lastUsed = time.Now()
+	h.sessions[id] = client
+
+	if !h.reaping && h.IdleTimeout > 0 {
+		h.reaping = true
+		go h.reapIdle(h.IdleTimeout)
+	}
+	return id
+}
+
+// reapIdle periodically ends sessions unused for longer than timeout
+func (h *StreamableHTTPHandler) reapIdle(timeout time.Duration) {
+	interval := timeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case now := <-ticker.C:
+			h.expireIdle(now, timeout)
+		}
+	}
+}
+
+// expireIdle ends the sessions that have had no request or open stream
+// since before now minus timeout, and returns how many it ended
+func (h *StreamableHTTPHandler) expireIdle(now time.Time, timeout time.Duration) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	expired := 0
+	for id, client := range h.sessions {
+		if client.active == 0 && now.Sub(client.lastUsed) > timeout {
+			close(client.done)
+			delete(h.sessions, id)
+			expired++
+		}
+	}
+	return expired
+}
+
+// Close ends every session, which closes their SSE streams, and stops
+// reaping idle ones
+func (h *StreamableHTTPHandler) Close() {
+	h.stopOnce.Do(func() { close(h.stop) })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, client := range h.sessions {
+		close(client.done)
+		delete(h.sessions, id)
+	}
+}
+
+// newSessionID returns a random, hard to guess session ID
+func newSessionID() string {
+	return rand.Text()
+}
+
+// allowedOrigin reports whether a request may be served. Requests without
+// an Origin header come from non-browser clients; browser requests must come
+// from the server's own host or from the local machine, which guards against
+// DNS rebinding.
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if u.Host == r.Host {
+		return true
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// acceptsJSON reports whether the client takes a JSON response. Clients
+// that only accept text/event-stream get the response as an event.
+func acceptsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, "application/json") || strings.Contains(accept, "*/*") {
+		return true
+	}
+	return !strings.Contains(accept, "text/event-stream")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+}
+
+// writeEvent writes a message as a server-sent event and flushes it
+func writeEvent(w http.ResponseWriter, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
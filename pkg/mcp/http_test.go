@@ -0,0 +1,275 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newHTTPTestServer(t *testing.T) (*httptest.Server, *StreamableHTTPHandler) {
+	t.Helper()
+
+	handler := NewStreamableHTTPHandler(newTestServer(t))
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.Close()
+		server.Close()
+	})
+	return server, handler
+}
+
+// postMessage POSTs a message and returns the response
+func postMessage(t *testing.T, url, sessionID string, message interface{}, header map[string]string) *http.Response {
+	t.Helper()
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set(SessionIDHeader, sessionID)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeResponse(t *testing.T, resp *http.Response) Response {
+	t.Helper()
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response
+}
+
+func initializeHTTPSession(t *testing.T, url, version string) string {
+	t.Helper()
+
+	resp := postMessage(t, url, "", Request{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "initialize",
+		Params:  json.RawMessage(`{"protocolVersion": "` + version + `", "capabilities": {}}`),
+	}, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 for initialize, got: %d", resp.StatusCode)
+	}
+
+	sessionID := resp.Header.Get(SessionIDHeader)
+	if sessionID == "" {
+		t.Fatal("Expected a session ID")
+	}
+
+	response := decodeResponse(t, resp)
+	result, _ := json.Marshal(response.Result)
+	if !strings.Contains(string(result), `"protocolVersion":"`+version+`"`) {
+		t.Errorf("Expected protocol version %s, got: %s", version, result)
+	}
+	return sessionID
+}
+
+func TestStreamableHTTP_SessionsNegotiateSeparately(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+
+	oldSession := initializeHTTPSession(t, server.URL, ProtocolVersion20241105)
+	newSession := initializeHTTPSession(t, server.URL, ProtocolVersion20250618)
+	if oldSession == newSession {
+		t.Fatal("Expected a new session ID per initialize")
+	}
+
+	// Notifications are acknowledged without a body
+	resp := postMessage(t, server.URL, newSession, Request{JSONRPC: "2.0", Method: "notifications/initialized"}, nil)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for a notification, got: %d", resp.StatusCode)
+	}
+
+	for sessionID, wantAnnotations := range map[string]bool{oldSession: false, newSession: true} {
+		resp := postMessage(t, server.URL, sessionID, Request{JSONRPC: "2.0", ID: 2, Method: "tools/list"}, nil)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("Expected a JSON response, got: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+
+		var response struct {
+			Result ListToolsResult `json:"result"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode tools: %v", err)
+		}
+		if got := response.Result.Tools[0].Annotations != nil; got != wantAnnotations {
+			t.Errorf("Session %s: expected annotations %v, got: %v", sessionID, wantAnnotations, got)
+		}
+	}
+}
+
+func TestStreamableHTTP_RejectsBadRequests(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	sessionID := initializeHTTPSession(t, server.URL, ProtocolVersion20250618)
+	ping := Request{JSONRPC: "2.0", ID: 1, Method: "ping"}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		header    map[string]string
+		want      int
+	}{
+		{"missing session", "", nil, http.StatusBadRequest},
+		{"unknown session", "unknown", nil, http.StatusNotFound},
+		{"unsupported version", sessionID, map[string]string{ProtocolVersionHeader: "2023-01-01"}, http.StatusBadRequest},
+		{"foreign origin", sessionID, map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"local origin", sessionID, map[string]string{"Origin": "http://localhost:3000"}, http.StatusOK},
+		{"valid", sessionID, map[string]string{ProtocolVersionHeader: ProtocolVersion20250618}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if resp := postMessage(t, server.URL, tt.sessionID, ping, tt.header); resp.StatusCode != tt.want {
+			t.Errorf("%s: expected %d, got: %d", tt.name, tt.want, resp.StatusCode)
+		}
+	}
+}
+
+func TestStreamableHTTP_EventStreamResponse(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	sessionID := initializeHTTPSession(t, server.URL, ProtocolVersion20250618)
+
+	resp := postMessage(t, server.URL, sessionID, Request{JSONRPC: "2.0", ID: "p", Method: "ping"},
+		map[string]string{"Accept": "text/event-stream"})
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got: %s", resp.Header.Get("Content-Type"))
+	}
+
+	var data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+			break
+		}
+	}
+	if data != `{"jsonrpc":"2.0","id":"p","result":{}}` {
+		t.Errorf("Unexpected event data: %q", data)
+	}
+}
+
+func TestStreamableHTTP_GetStreamAndDelete(t *testing.T) {
+	server, handler := newHTTPTestServer(t)
+	handler.KeepAlive = 10 * time.Millisecond
+	sessionID := initializeHTTPSession(t, server.URL, ProtocolVersion20250618)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(SessionIDHeader, sessionID)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK || stream.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got: %d %s", stream.StatusCode, stream.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(stream.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != ": keep-alive\n" {
+		t.Errorf("Expected a keep-alive comment, got: %q (%v)", line, err)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, server.URL, nil)
+	req.Header.Set(SessionIDHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 for DELETE, got: %d", resp.StatusCode)
+	}
+
+	// Ending the session closes its stream
+	done := make(chan struct{})
+	go func() {
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				close(done)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the stream to close with the session")
+	}
+
+	if resp := postMessage(t, server.URL, sessionID, Request{JSONRPC: "2.0", ID: 2, Method: "ping"}, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 after the session ended, got: %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableHTTP_FailedInitializeCreatesNoSession(t *testing.T) {
+	server, handler := newHTTPTestServer(t)
+
+	resp := postMessage(t, server.URL, "", Request{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "initialize",
+		Params:  json.RawMessage(`{"protocolVersion": 5}`),
+	}, nil)
+	if response := decodeResponse(t, resp); response.Error == nil {
+		t.Fatal("Expected an error for invalid initialize params")
+	}
+	if id := resp.Header.Get(SessionIDHeader); id != "" {
+		t.Errorf("Expected no session ID, got: %s", id)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if len(handler.sessions) != 0 {
+		t.Errorf("Expected no sessions, got: %d", len(handler.sessions))
+	}
+}
+
+func TestStreamableHTTP_ExpiresIdleSessions(t *testing.T) {
+	server, handler := newHTTPTestServer(t)
+	sessionID := initializeHTTPSession(t, server.URL, ProtocolVersion20250618)
+
+	client := handler.acquire(sessionID)
+	if expired := handler.expireIdle(time.Now().Add(2*time.Hour), time.Hour); expired != 0 {
+		t.Error("Expected sessions in use not to expire")
+	}
+	handler.release(client)
+
+	if expired := handler.expireIdle(time.Now(), time.Hour); expired != 0 {
+		t.Error("Expected recently used session not to expire")
+	}
+	if expired := handler.expireIdle(time.Now().Add(2*time.Hour), time.Hour); expired != 1 {
+		t.Fatalf("Expected 1 idle session to expire, got: %d", expired)
+	}
+
+	select {
+	case <-client.done:
+	default:
+		t.Error("Expected the expired session to be ended")
+	}
+	if resp := postMessage(t, server.URL, sessionID, Request{JSONRPC: "2.0", ID: 2, Method: "ping"}, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 after the session expired, got: %d", resp.StatusCode)
+	}
+}
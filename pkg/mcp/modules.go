@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+// moduleRequest holds the arguments of tools that operate on a module
+// directory, together with the terraform-ls session for its workspace
+type moduleRequest struct {
+	modulePath string
+	uri        string
+	client     *terraform.Client
+	release    func()
+}
+
+// openModuleRequest parses the workspace_path and module_path arguments and
+// acquires the session for the workspace. module_path defaults to the
+// workspace and may be relative to it. The caller must call release on the
+// returned request.
+func (s *Server) openModuleRequest(ctx context.Context, requestID interface{}, args map[string]interface{}) (*moduleRequest, *Response) {
+	workspacePath, ok := args["workspace_path"].(string)
+	if !ok {
+		resp := s.errorResponse(requestID, -32602, "workspace_path is required and must be a string")
+		return nil, &resp
+	}
+
+	modulePath := workspacePath
+	if value, ok := args["module_path"].(string); ok && value != "" {
+		modulePath = value
+		if !filepath.IsAbs(modulePath) {
+			modulePath = filepath.Join(workspacePath, modulePath)
+		}
+	}
+
+	absPath, err := filepath.Abs(modulePath)
+	if err != nil {
+		resp := s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get absolute path: %v", err))
+		return nil, &resp
+	}
+
+	tfClient, release, errResp := s.acquireWorkspace(ctx, requestID, workspacePath)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	return &moduleRequest{
+		modulePath: modulePath,
+		uri:        terraform.PathToURI(absPath),
+		client:     tfClient,
+		release:    release,
+	}, nil
+}
+
+// moduleToolSchema returns the input schema of a tool that operates on a
+// module directory
+func moduleToolSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the Terraform workspace directory",
+			},
+			"module_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the module directory, absolute or relative to the workspace (default: the workspace itself)",
+			},
+		},
+		"required": []string{"workspace_path"},
+	}
+}
+
+func moduleCallsTool() Tool {
+	return Tool{
+		Name:        "terraform_module_calls",
+		Description: "Show the tree of module calls of a Terraform module, with sources, versions and documentation links, and the modules in the workspace that call it",
+		InputSchema: moduleToolSchema(),
+	}
+}
+
+func moduleProvidersTool() Tool {
+	return Tool{
+		Name:        "terraform_module_providers",
+		Description: "Show the provider requirements of a Terraform module with the installed provider versions, and the required and installed Terraform versions",
+		InputSchema: moduleToolSchema(),
+	}
+}
+
+func initTool() Tool {
+	return Tool{
+		Name:        "terraform_init",
+		Description: "Run terraform init in a module directory through terraform-ls, installing providers and modules",
+		InputSchema: moduleToolSchema(),
+	}
+}
+
+func moduleValidateTool() Tool {
+	return Tool{
+		Name:        "terraform_module_validate",
+		Description: "Run terraform validate in a module directory through terraform-ls and return the diagnostics for every file of the module. Requires terraform init",
+		InputSchema: moduleToolSchema(),
+	}
+}
+
+func (s *Server) handleModuleCallsTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	module, errResp := s.openModuleRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer module.release()
+
+	calls, err := module.client.ModuleCalls(ctx, module.uri)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get module calls: %v", err))
+	}
+
+	callers, err := module.client.ModuleCallers(ctx, module.uri)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get module callers: %v", err))
+	}
+
+	return structuredResult(requestID, terraform.NewModuleCallTree(calls, callers),
+		formatModuleCalls(module.modulePath, calls, callers))
+}
+
+func (s *Server) handleModuleProvidersTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	module, errResp := s.openModuleRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer module.release()
+
+	providers, err := module.client.ModuleProviders(ctx, module.uri)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get module providers: %v", err))
+	}
+
+	version, err := module.client.ModuleTerraform(ctx, module.uri)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get Terraform version: %v", err))
+	}
+
+	return structuredResult(requestID, terraform.NewModuleProviderInfo(providers, version),
+		formatModuleProviders(module.modulePath, providers, version))
+}
+
+func (s *Server) handleInitTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	module, errResp := s.openModuleRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer module.release()
+
+	if err := module.client.TerraformInit(ctx, module.uri); err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to run terraform init: %v", err))
+	}
+
+	return textResult(requestID, fmt.Sprintf("terraform init completed for %s.", module.modulePath))
+}
+
+func (s *Server) handleModuleValidateTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	module, errResp := s.openModuleRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer module.release()
+
+	result, err := module.client.TerraformValidate(ctx, module.uri)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to run terraform validate: %v", err))
+	}
+
+	return structuredResult(requestID, result, formatModuleValidation(module.modulePath, result))
+}
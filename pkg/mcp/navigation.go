@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+func definitionTool() Tool {
+	properties := positionProperties()
+	properties["declaration"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Use textDocument/declaration instead of textDocument/definition",
+	}
+
+	return Tool{
+		Name:        "terraform_definition",
+		Description: "Find where the reference at a position, such as var.x, local.y, module.z or a resource, is defined in the workspace. Returns file paths, ranges and source snippets",
+		InputSchema: documentToolSchema("Path to the Terraform file containing the reference", properties, "line", "character"),
+	}
+}
+
+func referencesTool() Tool {
+	properties := positionProperties()
+	properties["include_declaration"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Include the declaration itself in the results (default: true)",
+	}
+
+	return Tool{
+		Name:        "terraform_references",
+		Description: "Find every reference to the variable, local, output, module or resource at a position across the workspace. Returns file paths, ranges and source snippets",
+		InputSchema: documentToolSchema("Path to the Terraform file containing the symbol", properties, "line", "character"),
+	}
+}
+
+func (s *Server) handleDefinitionTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	line, character, err := positionArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	declaration, _ := args["declaration"].(bool)
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	name := "Definition"
+	find := doc.client.Definition
+	if declaration {
+		name = "Declaration"
+		find = doc.client.Declaration
+	}
+
+	locations, err := find(ctx, doc.uri, doc.content, line, character)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to find %s: %v", name, err))
+	}
+
+	return textResult(requestID, formatLocations(
+		fmt.Sprintf("%s lookup completed for %s at line %d, character %d.", name, doc.filePath, line, character), locations))
+}
+
+func (s *Server) handleReferencesTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	line, character, err := positionArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	includeDeclaration := true
+	if value, ok := args["include_declaration"].(bool); ok {
+		includeDeclaration = value
+	}
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	locations, err := doc.client.References(ctx, doc.uri, doc.content, line, character, includeDeclaration)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to find references: %v", err))
+	}
+
+	return textResult(requestID, formatLocations(
+		fmt.Sprintf("References lookup completed for %s at line %d, character %d.", doc.filePath, line, character), locations))
+}
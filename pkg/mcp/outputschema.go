@@ -0,0 +1,155 @@
+package mcp
+
+// toolOutputSchemas describe the structured content of the tools that
+// return it, for clients that support structured tool output
+var toolOutputSchemas = map[string]map[string]interface{}{
+	"terraform_validate": objectSchema(map[string]interface{}{
+		"uri":         stringSchema(),
+		"diagnostics": arraySchema(diagnosticSchema()),
+		"relatedDocuments": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": arraySchema(diagnosticSchema()),
+		},
+		"timedOut": map[string]interface{}{"type": "boolean"},
+	}, "uri", "diagnostics"),
+	"terraform_module_calls":     moduleCallsSchema(),
+	"terraform_module_providers": moduleProvidersSchema(),
+	"terraform_module_validate": objectSchema(map[string]interface{}{
+		"uri": stringSchema(),
+		"diagnostics": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": arraySchema(diagnosticSchema()),
+		},
+		"timedOut": map[string]interface{}{"type": "boolean"},
+	}, "uri", "diagnostics"),
+	"terraform_reference_counts": objectSchema(map[string]interface{}{
+		"declarations": arraySchema(objectSchema(map[string]interface{}{
+			"kind":       stringSchema(),
+			"name":       stringSchema(),
+			"uri":        stringSchema(),
+			"range":      rangeSchema(),
+			"references": integerSchema(),
+		}, "kind", "name", "uri", "range", "references")),
+	}, "declarations"),
+	"terraform_enclosing_ranges": objectSchema(map[string]interface{}{
+		"blocks":     arraySchema(textRangeSchema()),
+		"attribute":  textRangeSchema(),
+		"expression": textRangeSchema(),
+	}),
+	"terraform_document_links": objectSchema(map[string]interface{}{
+		"blocks": arraySchema(objectSchema(map[string]interface{}{
+			"kind":   stringSchema(),
+			"type":   stringSchema(),
+			"name":   stringSchema(),
+			"source": stringSchema(),
+			"range":  rangeSchema(),
+			"url":    stringSchema(),
+		}, "kind", "name", "range")),
+	}, "blocks"),
+}
+
+// moduleCallsSchema returns the schema of terraform.ModuleCallTree. Module
+// calls nest, so they are described once and referenced.
+func moduleCallsSchema() map[string]interface{} {
+	moduleCall := map[string]interface{}{"$ref": "#/$defs/moduleCall"}
+
+	schema := objectSchema(map[string]interface{}{
+		"moduleCalls": arraySchema(moduleCall),
+		"callers": arraySchema(objectSchema(map[string]interface{}{
+			"uri": stringSchema(),
+		}, "uri")),
+	}, "moduleCalls", "callers")
+	schema["$defs"] = map[string]interface{}{
+		"moduleCall": objectSchema(map[string]interface{}{
+			"name":             stringSchema(),
+			"sourceAddr":       stringSchema(),
+			"version":          stringSchema(),
+			"sourceType":       stringSchema(),
+			"docsLink":         stringSchema(),
+			"dependentModules": arraySchema(moduleCall),
+		}, "name", "sourceAddr"),
+	}
+	return schema
+}
+
+// moduleProvidersSchema returns the schema of terraform.ModuleProviderInfo
+func moduleProvidersSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"providerRequirements": map[string]interface{}{
+			"type": "object",
+			"additionalProperties": objectSchema(map[string]interface{}{
+				"displayName":       stringSchema(),
+				"versionConstraint": stringSchema(),
+				"docsLink":          stringSchema(),
+			}, "displayName"),
+		},
+		"installedProviders": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": stringSchema(),
+		},
+		"terraform": objectSchema(map[string]interface{}{
+			"requiredVersion":   stringSchema(),
+			"discoveredVersion": stringSchema(),
+		}),
+	}, "providerRequirements", "installedProviders", "terraform")
+}
+
+// objectSchema returns the schema of an object with the given properties
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func arraySchema(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  "array",
+		"items": items,
+	}
+}
+
+func stringSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func integerSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "integer"}
+}
+
+// rangeSchema returns the schema of an LSP range, with 0-based lines and
+// characters
+func rangeSchema() map[string]interface{} {
+	position := objectSchema(map[string]interface{}{
+		"line":      integerSchema(),
+		"character": integerSchema(),
+	}, "line", "character")
+
+	return objectSchema(map[string]interface{}{
+		"start": position,
+		"end":   position,
+	}, "start", "end")
+}
+
+// textRangeSchema returns the schema of a range with the text it covers
+func textRangeSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"range": rangeSchema(),
+		"text":  stringSchema(),
+	}, "range", "text")
+}
+
+// diagnosticSchema returns the schema of an LSP diagnostic. Only the fields
+// clients are expected to read are described.
+func diagnosticSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"range":    rangeSchema(),
+		"severity": integerSchema(),
+		"source":   stringSchema(),
+		"message":  stringSchema(),
+	}, "range", "message")
+}
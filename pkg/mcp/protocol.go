@@ -0,0 +1,152 @@
+package mcp
+
+// MCP protocol revisions supported by the server
+const (
+	ProtocolVersion20241105 = "2024-11-05"
+	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20250618 = "2025-06-18"
+
+	// LatestProtocolVersion is offered to clients asking for a revision the
+	// server does not support
+	LatestProtocolVersion = ProtocolVersion20250618
+)
+
+// SupportedProtocolVersions lists the supported revisions, newest first
+var SupportedProtocolVersions = []string{
+	ProtocolVersion20250618,
+	ProtocolVersion20250326,
+	ProtocolVersion20241105,
+}
+
+// negotiateProtocolVersion picks the revision to use with a client. The
+// requested revision is used if the server supports it; otherwise the
+// latest one is offered and the client decides whether to continue.
+func negotiateProtocolVersion(requested string) string {
+	for _, version := range SupportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return LatestProtocolVersion
+}
+
+// protocolFeatures are the optional parts of the protocol that depend on
+// the negotiated revision
+type protocolFeatures struct {
+	// Tool annotations, added in 2025-03-26
+	toolAnnotations bool
+
+	// Tool titles, structured tool output with output schemas, and _meta
+	// on tool results, added in 2025-06-18
+	toolTitles        bool
+	structuredContent bool
+	resultMeta        bool
+}
+
+// featuresFor returns the features of a protocol revision. Revisions are
+// dates, so they compare in order as strings.
+func featuresFor(version string) protocolFeatures {
+	return protocolFeatures{
+		toolAnnotations:   version >= ProtocolVersion20250326,
+		toolTitles:        version >= ProtocolVersion20250618,
+		structuredContent: version >= ProtocolVersion20250618,
+		resultMeta:        version >= ProtocolVersion20250618,
+	}
+}
+
+// toolHints describes the behaviour of each tool for clients that support
+// tool annotations. Tools not listed only read files and terraform-ls state.
+var toolHints = map[string]ToolAnnotations{
+	"terraform_rename": {
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(false),
+		OpenWorldHint:   boolPtr(false),
+	},
+	"terraform_code_actions": {
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(false),
+		OpenWorldHint:   boolPtr(false),
+	},
+	"terraform_init": {
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(false),
+		IdempotentHint:  boolPtr(true),
+		OpenWorldHint:   boolPtr(true),
+	},
+}
+
+// toolTitles are the human readable names of the tools
+var toolTitles = map[string]string{
+	"terraform_validate":         "Validate Terraform file",
+	"terraform_format":           "Format Terraform file",
+	"terraform_completion":       "Complete Terraform configuration",
+	"terraform_hover":            "Show Terraform documentation",
+	"terraform_definition":       "Go to definition",
+	"terraform_references":       "Find references",
+	"terraform_symbols":          "List symbols",
+	"terraform_rename":           "Rename symbol",
+	"terraform_code_actions":     "Code actions",
+	"terraform_module_calls":     "List module calls",
+	"terraform_module_providers": "List module providers",
+	"terraform_init":             "Run terraform init",
+	"terraform_module_validate":  "Run terraform validate",
+	"terraform_semantic_tokens":  "Classify tokens",
+	"terraform_signature_help":   "Show function signature",
+	"terraform_reference_counts": "Count references",
+	"terraform_enclosing_ranges": "Get enclosing block ranges",
+	"terraform_document_links":   "List documentation links",
+}
+
+// toolsForFeatures adds the titles, output schemas and annotations the
+// client understands to tool definitions
+func toolsForFeatures(tools []Tool, features protocolFeatures) []Tool {
+	for i := range tools {
+		title := toolTitles[tools[i].Name]
+
+		if features.toolTitles {
+			tools[i].Title = title
+		}
+
+		if features.structuredContent {
+			tools[i].OutputSchema = toolOutputSchemas[tools[i].Name]
+		}
+
+		if features.toolAnnotations {
+			annotations, ok := toolHints[tools[i].Name]
+			if !ok {
+				annotations = ToolAnnotations{
+					ReadOnlyHint:  boolPtr(true),
+					OpenWorldHint: boolPtr(false),
+				}
+			}
+			annotations.Title = title
+			tools[i].Annotations = &annotations
+		}
+	}
+	return tools
+}
+
+// responseForFeatures removes the parts of a tool result the client does not
+// understand
+func responseForFeatures(response Response, features protocolFeatures) Response {
+	result, ok := response.Result.(CallToolResult)
+	if !ok {
+		return response
+	}
+
+	if !features.structuredContent {
+		result.StructuredContent = nil
+	}
+	if !features.resultMeta {
+		result.Meta = nil
+	}
+
+	response.Result = result
+	return response
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+func referenceCountsTool() Tool {
+	schema := moduleToolSchema()
+	properties := schema["properties"].(map[string]interface{})
+	properties["unreferenced_only"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Only list declarations that nothing references",
+	}
+
+	return Tool{
+		Name:        "terraform_reference_counts",
+		Description: "List every variable, output and local value declared in the .tf files of a module with how many places reference it, to find dead code. Outputs are usually referenced by calling modules rather than within their own module",
+		InputSchema: schema,
+	}
+}
+
+func (s *Server) handleReferenceCountsTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	unreferencedOnly, _ := args["unreferenced_only"].(bool)
+
+	module, errResp := s.openModuleRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer module.release()
+
+	dir := terraform.URIToPath(module.uri)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to read module directory: %v", err))
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tf") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var counts []terraform.ReferenceCount
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to read %s: %v", path, err))
+		}
+
+		fileCounts, err := referenceCounts(ctx, module.client, terraform.PathToURI(path), string(content))
+		if err != nil {
+			return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to count references in %s: %v", path, err))
+		}
+		counts = append(counts, fileCounts...)
+	}
+
+	structured := []terraform.ReferenceCount{}
+	for _, count := range counts {
+		if !unreferencedOnly || count.References == 0 {
+			structured = append(structured, count)
+		}
+	}
+
+	return structuredResult(requestID, map[string]interface{}{"declarations": structured},
+		formatReferenceCounts(module.modulePath, counts, unreferencedOnly))
+}
+
+// referenceCounts counts the references of the declarations in one file of
+// a module, holding the document while its content is in use
+func referenceCounts(ctx context.Context, client *terraform.Client, uri, content string) ([]terraform.ReferenceCount, error) {
+	unlock, err := client.LockDocument(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return client.ReferenceCounts(ctx, uri, content)
+}
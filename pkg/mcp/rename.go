@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+func renameTool() Tool {
+	properties := positionProperties()
+	properties["new_name"] = map[string]interface{}{
+		"type":        "string",
+		"description": "New name for the symbol",
+	}
+	properties["apply"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Write the changes to disk. By default only the per-file diffs are returned (dry run)",
+	}
+
+	return Tool{
+		Name:        "terraform_rename",
+		Description: "Rename the variable, local, output, module or resource at a position everywhere it is used in the workspace. Returns a diff per file; set apply to write the files",
+		InputSchema: documentToolSchema("Path to the Terraform file containing the symbol", properties, "line", "character", "new_name"),
+	}
+}
+
+func (s *Server) handleRenameTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	line, character, err := positionArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	newName, ok := args["new_name"].(string)
+	if !ok || newName == "" {
+		return s.errorResponse(requestID, -32602, "new_name is required and must be a non-empty string")
+	}
+	apply, _ := args["apply"].(bool)
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	prepared, err := doc.client.PrepareRename(ctx, doc.uri, doc.content, line, character)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to prepare rename: %v", err))
+	}
+	if prepared == nil {
+		return s.errorResponse(requestID, -32602, fmt.Sprintf("Nothing to rename in %s at line %d, character %d", doc.filePath, line, character))
+	}
+
+	edit, err := doc.client.Rename(ctx, doc.uri, doc.content, line, character, newName)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to rename: %v", err))
+	}
+
+	changes, err := doc.client.ResolveWorkspaceEdit(*edit)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to resolve rename edits: %v", err))
+	}
+
+	if apply {
+		if err := doc.client.ApplyFileChanges(ctx, changes, doc.uri); err != nil {
+			return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to apply rename: %v", err))
+		}
+	}
+
+	oldName := prepared.Placeholder
+	if oldName == "" && !prepared.DefaultBehavior {
+		oldName = textInRange(doc.content, prepared.Range)
+	}
+
+	var summary string
+	if oldName != "" {
+		summary = fmt.Sprintf("Rename of %q to %q", oldName, newName)
+	} else {
+		summary = fmt.Sprintf("Rename to %q", newName)
+	}
+	if apply {
+		summary += " applied."
+	} else {
+		summary += " (dry run, no files written)."
+	}
+
+	return textResult(requestID, formatFileChanges(summary, changes))
+}
+
+// textInRange returns the text of content covered by r, or an empty string
+// if the range is invalid
+func textInRange(content string, r terraform.Range) string {
+	start, err := terraform.OffsetAt(content, r.Start)
+	if err != nil {
+		return ""
+	}
+	end, err := terraform.OffsetAt(content, r.End)
+	if err != nil || end < start {
+		return ""
+	}
+	return strings.TrimSpace(content[start:end])
+}
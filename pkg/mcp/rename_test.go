@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+func TestServer_RenameToolApplies(t *testing.T) {
+	server, fake := newFakeServer(t)
+
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.tf")
+	variablesPath := filepath.Join(dir, "variables.tf")
+	mainContent := "provider \"aws\" {\n  region = var.region\n}\n"
+	if err := os.WriteFile(mainPath, []byte(mainContent), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(variablesPath, []byte("variable \"region\" {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	mainURI := terraform.PathToURI(mainPath)
+
+	fake.Handle("textDocument/prepareRename", func(params json.RawMessage) (interface{}, error) {
+		return terraform.Range{Start: terraform.Position{Line: 1, Character: 15}, End: terraform.Position{Line: 1, Character: 21}}, nil
+	})
+	fake.Handle("textDocument/rename", func(params json.RawMessage) (interface{}, error) {
+		return terraform.WorkspaceEdit{
+			Changes: map[string][]terraform.TextEdit{
+				mainURI:                            {{Range: terraform.Range{Start: terraform.Position{Line: 1, Character: 15}, End: terraform.Position{Line: 1, Character: 21}}, NewText: "aws_region"}},
+				terraform.PathToURI(variablesPath): {{Range: terraform.Range{Start: terraform.Position{Character: 10}, End: terraform.Position{Character: 16}}, NewText: "aws_region"}},
+			},
+		}, nil
+	})
+
+	result := callTool(t, server, "terraform_rename", map[string]interface{}{
+		"workspace_path": dir,
+		"file_path":      mainPath,
+		"content":        mainContent,
+		"line":           1,
+		"character":      17,
+		"new_name":       "aws_region",
+		"apply":          true,
+	})
+	if !strings.Contains(result.Content[0].Text, `Rename of "region" to "aws_region" applied.`) {
+		t.Errorf("Expected an applied rename summary, got: %s", result.Content[0].Text)
+	}
+
+	want := map[string]string{
+		mainPath:      "provider \"aws\" {\n  region = var.aws_region\n}\n",
+		variablesPath: "variable \"aws_region\" {}\n",
+	}
+	for path, content := range want {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to be %q, got: %q", path, content, data)
+		}
+	}
+
+	// The renamed document stays in sync although the request holds it
+	params := waitForDidChange(t, fake, 1)
+	if params.TextDocument.URI != mainURI || params.ContentChanges[0].Text != want[mainPath] {
+		t.Errorf("Expected main.tf to be synced with the renamed content, got: %+v", params)
+	}
+}
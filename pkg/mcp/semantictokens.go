@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+func semanticTokensTool() Tool {
+	return Tool{
+		Name:        "terraform_semantic_tokens",
+		Description: "Classify the tokens of a Terraform file, telling block types, labels, attribute names, references and function calls apart. Returns one token per line with its position, type, modifiers and text. Without a range the whole file is used",
+		InputSchema: documentToolSchema("Path to the specific Terraform file", rangeProperties()),
+	}
+}
+
+func (s *Server) handleSemanticTokensTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	r, err := rangeArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	var tokens []terraform.SemanticToken
+	if r != nil {
+		tokens, err = doc.client.SemanticTokensRange(ctx, doc.uri, doc.content, *r)
+	} else {
+		tokens, err = doc.client.SemanticTokens(ctx, doc.uri, doc.content)
+	}
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get semantic tokens: %v", err))
+	}
+
+	return textResult(requestID, formatSemanticTokens(doc.filePath, tokens))
+}
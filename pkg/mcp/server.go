@@ -4,26 +4,62 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"path/filepath"
 
 	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
 )
 
+// defaultCompletionLimit is the number of completion items returned when
+// the caller does not set a limit
+const defaultCompletionLimit = 50
+
 // Server represents an MCP server
 type Server struct {
-	tfClient *terraform.Client
+	sessions *terraform.SessionManager
+
+	// session is used for requests without a session in their context
+	session *Session
 }
 
-// NewServer creates a new MCP server
-func NewServer(tfClient *terraform.Client) *Server {
+// NewServer creates a new MCP server that runs tools against the
+// terraform-ls sessions of the given manager
+func NewServer(sessions *terraform.SessionManager) *Server {
 	return &Server{
-		tfClient: tfClient,
+		sessions: sessions,
+		session:  NewSession(),
+	}
+}
+
+// HandleRequest handles an incoming MCP message and returns the response to
+// send. Notifications, and requests the client cancelled while they were
+// being handled, get no response and nil is returned.
+func (s *Server) HandleRequest(ctx context.Context, request Request) *Response {
+	if request.IsNotification() {
+		s.handleNotification(ctx, request)
+		return nil
+	}
+
+	ctx, finish := s.sessionFor(ctx).begin(ctx, request.ID)
+	return s.handleTracked(ctx, request, finish)
+}
+
+// handleTracked handles a request registered with Session.begin, dropping
+// the response if the client cancelled the request meanwhile
+func (s *Server) handleTracked(ctx context.Context, request Request, finish func() bool) *Response {
+	response := s.handleRequest(ctx, request)
+	if cancelled := finish(); cancelled {
+		return nil
 	}
+	return &response
 }
 
-// HandleRequest handles incoming MCP requests
-func (s *Server) HandleRequest(ctx context.Context, request Request) Response {
+func (s *Server) handleRequest(ctx context.Context, request Request) Response {
 	switch request.Method {
+	case "ping":
+		return Response{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Result:  struct{}{},
+		}
 	case "initialize":
 		return s.handleInitialize(ctx, request)
 	case "tools/list":
@@ -42,6 +78,21 @@ func (s *Server) HandleRequest(ctx context.Context, request Request) Response {
 	}
 }
 
+// handleNotification handles a message that expects no response. Unknown
+// notifications are ignored.
+func (s *Server) handleNotification(ctx context.Context, notification Request) {
+	switch notification.Method {
+	case "notifications/initialized":
+		s.sessionFor(ctx).setInitialized()
+	case "notifications/cancelled":
+		var params CancelledNotificationParams
+		if err := json.Unmarshal(notification.Params, &params); err != nil || params.RequestID == nil {
+			return
+		}
+		s.sessionFor(ctx).cancelRequest(params.RequestID)
+	}
+}
+
 func (s *Server) handleInitialize(ctx context.Context, request Request) Response {
 	var params InitializeParams
 	if err := json.Unmarshal(request.Params, &params); err != nil {
@@ -56,8 +107,11 @@ func (s *Server) handleInitialize(ctx context.Context, request Request) Response
 		}
 	}
 
+	version := negotiateProtocolVersion(params.ProtocolVersion)
+	s.sessionFor(ctx).setProtocolVersion(version)
+
 	result := InitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: version,
 		Capabilities: ServerCapabilities{
 			Tools: &ToolsCapability{},
 		},
@@ -100,25 +154,8 @@ func (s *Server) handleListTools(ctx context.Context, request Request) Response
 		},
 		{
 			Name:        "terraform_format",
-			Description: "Format Terraform configuration files",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"workspace_path": map[string]interface{}{
-						"type":        "string",
-						"description": "Path to the Terraform workspace directory",
-					},
-					"file_path": map[string]interface{}{
-						"type":        "string",
-						"description": "Path to the specific Terraform file to format",
-					},
-					"content": map[string]interface{}{
-						"type":        "string",
-						"description": "Content of the Terraform file to format",
-					},
-				},
-				"required": []string{"workspace_path", "file_path", "content"},
-			},
+			Description: "Format Terraform configuration files, optionally only the lines covered by a range or as after typing a character",
+			InputSchema: documentToolSchema("Path to the specific Terraform file to format", formatProperties()),
 		},
 		{
 			Name:        "terraform_completion",
@@ -146,17 +183,44 @@ func (s *Server) handleListTools(ctx context.Context, request Request) Response
 						"type":        "integer",
 						"description": "Character position (0-based)",
 					},
+					"keep_snippets": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Return insert text with snippet placeholders such as ${1:name} instead of expanding them",
+					},
+					"resolve": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Ask terraform-ls for documentation of items that do not include it",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum number of suggestions to return (default %d)", defaultCompletionLimit),
+					},
 				},
 				"required": []string{"workspace_path", "file_path", "content", "line", "character"},
 			},
 		},
+		hoverTool(),
+		definitionTool(),
+		referencesTool(),
+		symbolsTool(),
+		renameTool(),
+		codeActionsTool(),
+		moduleCallsTool(),
+		moduleProvidersTool(),
+		initTool(),
+		moduleValidateTool(),
+		semanticTokensTool(),
+		signatureHelpTool(),
+		referenceCountsTool(),
+		enclosingRangesTool(),
+		documentLinksTool(),
 	}
 
 	return Response{
 		JSONRPC: "2.0",
 		ID:      request.ID,
 		Result: ListToolsResult{
-			Tools: tools,
+			Tools: toolsForFeatures(tools, featuresFor(s.sessionFor(ctx).ProtocolVersion())),
 		},
 	}
 }
@@ -175,17 +239,51 @@ func (s *Server) handleCallTool(ctx context.Context, request Request) Response {
 		}
 	}
 
+	return responseForFeatures(s.callTool(ctx, request.ID, params), featuresFor(s.sessionFor(ctx).ProtocolVersion()))
+}
+
+func (s *Server) callTool(ctx context.Context, requestID interface{}, params CallToolParams) Response {
 	switch params.Name {
 	case "terraform_validate":
-		return s.handleValidateTool(ctx, request.ID, params.Arguments)
+		return s.handleValidateTool(ctx, requestID, params.Arguments)
 	case "terraform_format":
-		return s.handleFormatTool(ctx, request.ID, params.Arguments)
+		return s.handleFormatTool(ctx, requestID, params.Arguments)
 	case "terraform_completion":
-		return s.handleCompletionTool(ctx, request.ID, params.Arguments)
+		return s.handleCompletionTool(ctx, requestID, params.Arguments)
+	case "terraform_hover":
+		return s.handleHoverTool(ctx, requestID, params.Arguments)
+	case "terraform_definition":
+		return s.handleDefinitionTool(ctx, requestID, params.Arguments)
+	case "terraform_references":
+		return s.handleReferencesTool(ctx, requestID, params.Arguments)
+	case "terraform_symbols":
+		return s.handleSymbolsTool(ctx, requestID, params.Arguments)
+	case "terraform_rename":
+		return s.handleRenameTool(ctx, requestID, params.Arguments)
+	case "terraform_code_actions":
+		return s.handleCodeActionsTool(ctx, requestID, params.Arguments)
+	case "terraform_module_calls":
+		return s.handleModuleCallsTool(ctx, requestID, params.Arguments)
+	case "terraform_module_providers":
+		return s.handleModuleProvidersTool(ctx, requestID, params.Arguments)
+	case "terraform_init":
+		return s.handleInitTool(ctx, requestID, params.Arguments)
+	case "terraform_module_validate":
+		return s.handleModuleValidateTool(ctx, requestID, params.Arguments)
+	case "terraform_semantic_tokens":
+		return s.handleSemanticTokensTool(ctx, requestID, params.Arguments)
+	case "terraform_signature_help":
+		return s.handleSignatureHelpTool(ctx, requestID, params.Arguments)
+	case "terraform_reference_counts":
+		return s.handleReferenceCountsTool(ctx, requestID, params.Arguments)
+	case "terraform_enclosing_ranges":
+		return s.handleEnclosingRangesTool(ctx, requestID, params.Arguments)
+	case "terraform_document_links":
+		return s.handleDocumentLinksTool(ctx, requestID, params.Arguments)
 	default:
 		return Response{
 			JSONRPC: "2.0",
-			ID:      request.ID,
+			ID:      requestID,
 			Error: &Error{
 				Code:    -32602,
 				Message: fmt.Sprintf("Unknown tool: %s", params.Name),
@@ -195,159 +293,119 @@ func (s *Server) handleCallTool(ctx context.Context, request Request) Response {
 }
 
 func (s *Server) handleValidateTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
-	workspacePath, ok := args["workspace_path"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "workspace_path is required and must be a string")
-	}
-
-	filePath, ok := args["file_path"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "file_path is required and must be a string")
-	}
-
-	content, ok := args["content"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "content is required and must be a string")
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
 	}
-
-	// Initialize terraform-ls with workspace
-	if err := s.tfClient.Initialize(ctx, workspacePath); err != nil {
-		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to initialize terraform-ls: %v", err))
-	}
-
-	// Create file URI
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get absolute path: %v", err))
-	}
-	uri := fmt.Sprintf("file://%s", absPath)
+	defer doc.release()
 
 	// Validate document
-	result, err := s.tfClient.ValidateDocument(ctx, uri, content)
+	result, err := doc.client.ValidateDocument(ctx, doc.uri, doc.content)
 	if err != nil {
 		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to validate document: %v", err))
 	}
-
-	return Response{
-		JSONRPC: "2.0",
-		ID:      requestID,
-		Result: CallToolResult{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("Validation completed for %s. Found %d diagnostic(s).", filePath, len(result.Diagnostics)),
-				},
-			},
-		},
+	if result.Diagnostics == nil {
+		result.Diagnostics = []terraform.Diagnostic{}
 	}
+
+	return structuredResult(requestID, result, formatValidationResult(doc.filePath, result))
 }
 
 func (s *Server) handleFormatTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
-	workspacePath, ok := args["workspace_path"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "workspace_path is required and must be a string")
+	r, err := rangeArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
 	}
-
-	filePath, ok := args["file_path"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "file_path is required and must be a string")
+	opts, err := formattingOptionsArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
 	}
 
-	content, ok := args["content"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "content is required and must be a string")
+	typed, _ := args["typed_character"].(string)
+	var line, character int
+	if typed != "" {
+		if r != nil {
+			return s.errorResponse(requestID, -32602, "typed_character cannot be combined with a range")
+		}
+		if line, character, err = positionArgs(args); err != nil {
+			return s.errorResponse(requestID, -32602, err.Error())
+		}
 	}
 
-	// Initialize terraform-ls with workspace
-	if err := s.tfClient.Initialize(ctx, workspacePath); err != nil {
-		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to initialize terraform-ls: %v", err))
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
 	}
+	defer doc.release()
 
-	// Create file URI
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get absolute path: %v", err))
+	// Format document, only the requested range, or as after typing
+	var result *terraform.FormatResult
+	switch {
+	case typed != "":
+		result, err = doc.client.FormatOnType(ctx, doc.uri, doc.content, line, character, typed, opts)
+	case r != nil:
+		result, err = doc.client.FormatRange(ctx, doc.uri, doc.content, *r, opts)
+	default:
+		result, err = doc.client.FormatDocument(ctx, doc.uri, doc.content, opts)
 	}
-	uri := fmt.Sprintf("file://%s", absPath)
-
-	// Format document
-	result, err := s.tfClient.FormatDocument(ctx, uri, content)
 	if err != nil {
 		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to format document: %v", err))
 	}
 
-	return Response{
-		JSONRPC: "2.0",
-		ID:      requestID,
-		Result: CallToolResult{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("Formatting completed for %s. Applied %d edit(s).", filePath, len(result.Edits)),
-				},
-			},
-		},
+	summary := fmt.Sprintf("Formatting completed for %s. Applied %d edit(s).", doc.filePath, len(result.Edits))
+	if diff := terraform.UnifiedDiff(doc.filePath, doc.filePath, doc.content, result.Formatted); diff != "" {
+		summary += "\n\n" + diff
+	} else {
+		summary += " The file is already formatted."
 	}
+
+	return textResult(requestID, summary, result.Formatted)
 }
 
 func (s *Server) handleCompletionTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
-	workspacePath, ok := args["workspace_path"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "workspace_path is required and must be a string")
-	}
-
-	filePath, ok := args["file_path"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "file_path is required and must be a string")
-	}
-
-	content, ok := args["content"].(string)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "content is required and must be a string")
-	}
-
-	lineFloat, ok := args["line"].(float64)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "line is required and must be a number")
+	line, character, err := positionArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
 	}
-	line := int(lineFloat)
 
-	characterFloat, ok := args["character"].(float64)
-	if !ok {
-		return s.errorResponse(requestID, -32602, "character is required and must be a number")
-	}
-	character := int(characterFloat)
+	keepSnippets, _ := args["keep_snippets"].(bool)
+	resolve, _ := args["resolve"].(bool)
 
-	// Initialize terraform-ls with workspace
-	if err := s.tfClient.Initialize(ctx, workspacePath); err != nil {
-		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to initialize terraform-ls: %v", err))
+	limit := defaultCompletionLimit
+	if limitFloat, ok := args["limit"].(float64); ok && limitFloat > 0 {
+		limit = int(limitFloat)
 	}
 
-	// Create file URI
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get absolute path: %v", err))
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
 	}
-	uri := fmt.Sprintf("file://%s", absPath)
+	defer doc.release()
 
 	// Get completion
-	result, err := s.tfClient.GetCompletion(ctx, uri, content, line, character)
+	result, err := doc.client.GetCompletion(ctx, doc.uri, doc.content, line, character)
 	if err != nil {
 		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get completion: %v", err))
 	}
 
-	return Response{
-		JSONRPC: "2.0",
-		ID:      requestID,
-		Result: CallToolResult{
-			Content: []Content{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("Completion completed for %s at line %d, character %d. Found %d suggestion(s).", filePath, line, character, len(result.Items)),
-				},
-			},
-		},
+	items := result.Items
+	if len(items) > limit {
+		items = items[:limit]
 	}
+
+	if resolve {
+		for i, item := range items {
+			if item.Documentation != "" {
+				continue
+			}
+			// Resolution is best effort; keep the original item on failure
+			if resolved, err := doc.client.ResolveCompletionItem(ctx, item); err == nil {
+				items[i] = *resolved
+			}
+		}
+	}
+
+	return textResult(requestID, formatCompletionResult(doc.filePath, line, character, result, items, keepSnippets))
 }
 
 func (s *Server) errorResponse(id interface{}, code int, message string) Response {
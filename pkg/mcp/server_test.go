@@ -3,15 +3,98 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp/lsptest"
 	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
 )
 
+// newTestServer returns a server whose sessions are never started by the
+// tests that use it
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	sessions := terraform.NewSessionManager(terraform.SessionOptions{})
+	t.Cleanup(func() { sessions.Close() })
+
+	return NewServer(sessions)
+}
+
+// newFakeServer returns a server whose sessions run on a scripted fake
+// terraform-ls, for tests that call tools end to end
+func newFakeServer(t *testing.T) (*Server, *lsptest.Server) {
+	t.Helper()
+
+	fake := lsptest.NewServer()
+	t.Cleanup(func() { fake.Close() })
+	fake.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"capabilities": map[string]interface{}{}}, nil
+	})
+	fake.Handle("shutdown", func(params json.RawMessage) (interface{}, error) {
+		return nil, nil
+	})
+
+	sessions := terraform.NewSessionManager(terraform.SessionOptions{
+		NewClient: func() (*terraform.Client, error) {
+			return terraform.NewClientWithLSP(fake.Client()), nil
+		},
+	})
+	t.Cleanup(func() { sessions.Close() })
+
+	return NewServer(sessions), fake
+}
+
+// callTool calls a tool and returns its result, failing the test on a
+// JSON-RPC or tool error
+func callTool(t *testing.T, server *Server, name string, arguments map[string]interface{}) CallToolResult {
+	t.Helper()
+
+	params, err := json.Marshal(CallToolParams{Name: name, Arguments: arguments})
+	if err != nil {
+		t.Fatalf("Failed to marshal call params: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response := server.HandleRequest(ctx, Request{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+	if response.Error != nil {
+		t.Fatalf("Failed to call %s: %+v", name, response.Error)
+	}
+	result := response.Result.(CallToolResult)
+	if result.IsError {
+		t.Fatalf("Expected %s to succeed, got: %+v", name, result.Content)
+	}
+	return result
+}
+
+// waitForDidChange waits for the n-th didChange sent to the fake server and
+// returns its parameters
+func waitForDidChange(t *testing.T, fake *lsptest.Server, n int) terraform.DidChangeTextDocumentParams {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	messages := fake.WaitFor("textDocument/didChange", n, ctx.Done())
+	if len(messages) < n {
+		t.Fatalf("Expected %d didChange notification(s), got: %d", n, len(messages))
+	}
+	var params terraform.DidChangeTextDocumentParams
+	if err := json.Unmarshal(messages[n-1].Params, &params); err != nil {
+		t.Fatalf("Failed to decode didChange: %v", err)
+	}
+	return params
+}
+
 func TestServer_HandleInitialize(t *testing.T) {
-	// Create a mock terraform client
-	tfClient := &terraform.Client{}
-	server := NewServer(tfClient)
+	server := newTestServer(t)
 
 	// Create initialize request
 	initParams := InitializeParams{
@@ -69,8 +152,7 @@ func TestServer_HandleInitialize(t *testing.T) {
 }
 
 func TestServer_HandleListTools(t *testing.T) {
-	tfClient := &terraform.Client{}
-	server := NewServer(tfClient)
+	server := newTestServer(t)
 
 	request := Request{
 		JSONRPC: "2.0",
@@ -96,7 +178,26 @@ func TestServer_HandleListTools(t *testing.T) {
 		t.Errorf("Expected ListToolsResult, got: %T", response.Result)
 	}
 
-	expectedTools := []string{"terraform_validate", "terraform_format", "terraform_completion"}
+	expectedTools := []string{
+		"terraform_validate",
+		"terraform_format",
+		"terraform_completion",
+		"terraform_hover",
+		"terraform_definition",
+		"terraform_references",
+		"terraform_symbols",
+		"terraform_rename",
+		"terraform_code_actions",
+		"terraform_module_calls",
+		"terraform_module_providers",
+		"terraform_init",
+		"terraform_module_validate",
+		"terraform_semantic_tokens",
+		"terraform_signature_help",
+		"terraform_reference_counts",
+		"terraform_enclosing_ranges",
+		"terraform_document_links",
+	}
 	if len(result.Tools) != len(expectedTools) {
 		t.Errorf("Expected %d tools, got %d", len(expectedTools), len(result.Tools))
 	}
@@ -113,8 +214,7 @@ func TestServer_HandleListTools(t *testing.T) {
 }
 
 func TestServer_HandleUnknownMethod(t *testing.T) {
-	tfClient := &terraform.Client{}
-	server := NewServer(tfClient)
+	server := newTestServer(t)
 
 	request := Request{
 		JSONRPC: "2.0",
@@ -140,8 +240,7 @@ func TestServer_HandleUnknownMethod(t *testing.T) {
 }
 
 func TestServer_HandleCallToolInvalidParams(t *testing.T) {
-	tfClient := &terraform.Client{}
-	server := NewServer(tfClient)
+	server := newTestServer(t)
 
 	// Invalid JSON params
 	invalidParams := json.RawMessage(`{"invalid": "json"`)
@@ -164,4 +263,330 @@ func TestServer_HandleCallToolInvalidParams(t *testing.T) {
 	if response.Error.Code != -32602 {
 		t.Errorf("Expected error code -32602, got: %d", response.Error.Code)
 	}
-}
\ No newline at end of file
+}
+
+func TestServer_FormatRejectsInvalidTypedCharacter(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := map[string]string{
+		"with range":       `{"workspace_path": "/workspace", "file_path": "main.tf", "content": "", "typed_character": "}", "line": 0, "character": 1, "start_line": 0, "start_character": 0, "end_line": 1, "end_character": 0}`,
+		"without position": `{"workspace_path": "/workspace", "file_path": "main.tf", "content": "", "typed_character": "}"}`,
+	}
+
+	for name, arguments := range tests {
+		response := server.HandleRequest(context.Background(), Request{
+			JSONRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name": "terraform_format", "arguments": ` + arguments + `}`),
+		})
+		if response.Error == nil || response.Error.Code != -32602 {
+			t.Errorf("%s: expected invalid params error, got: %+v", name, response.Error)
+		}
+	}
+}
+
+func TestServer_FormatToolAppliesWhitespaceOptions(t *testing.T) {
+	server, fake := newFakeServer(t)
+	fake.Handle("textDocument/formatting", func(params json.RawMessage) (interface{}, error) {
+		return []terraform.TextEdit{
+			{Range: terraform.Range{Start: terraform.Position{Line: 1}, End: terraform.Position{Line: 1, Character: 6}}, NewText: "  foo = 1"},
+		}, nil
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"trims", "locals {  \n\tfoo=1\n}\n\n\n", "locals {\n  foo = 1\n}\n"},
+		{"inserts", "locals {\n\tfoo=1\n}", "locals {\n  foo = 1\n}\n"},
+	}
+
+	for _, tt := range tests {
+		result := callTool(t, server, "terraform_format", map[string]interface{}{
+			"workspace_path":           "/workspace",
+			"file_path":                "/workspace/" + tt.name + ".tf",
+			"content":                  tt.content,
+			"trim_trailing_whitespace": true,
+			"insert_final_newline":     true,
+			"trim_final_newlines":      true,
+		})
+		if len(result.Content) != 2 || result.Content[1].Text != tt.want {
+			t.Errorf("%s: expected %q, got: %+v", tt.name, tt.want, result.Content)
+		}
+	}
+}
+
+func initializeSession(t *testing.T, server *Server, ctx context.Context, version string) string {
+	t.Helper()
+
+	params, err := json.Marshal(InitializeParams{ProtocolVersion: version})
+	if err != nil {
+		t.Fatalf("Failed to marshal init params: %v", err)
+	}
+	response := server.HandleRequest(ctx, Request{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: params})
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got: %v", response.Error)
+	}
+	return response.Result.(InitializeResult).ProtocolVersion
+}
+
+func TestServer_NegotiatesProtocolVersion(t *testing.T) {
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{"2024-11-05", "2024-11-05"},
+		{"2025-03-26", "2025-03-26"},
+		{"2025-06-18", "2025-06-18"},
+		{"2099-01-01", LatestProtocolVersion},
+		{"", LatestProtocolVersion},
+	}
+
+	for _, tt := range tests {
+		server := newTestServer(t)
+		session := NewSession()
+		ctx := WithSession(context.Background(), session)
+
+		if got := initializeSession(t, server, ctx, tt.requested); got != tt.want {
+			t.Errorf("Requested %q: expected %q, got: %q", tt.requested, tt.want, got)
+		}
+		if got := session.ProtocolVersion(); got != tt.want {
+			t.Errorf("Requested %q: expected the session to record %q, got: %q", tt.requested, tt.want, got)
+		}
+		if got := server.session.ProtocolVersion(); got != ProtocolVersion20241105 {
+			t.Errorf("Expected the default session to be untouched, got: %q", got)
+		}
+	}
+}
+
+func TestServer_ListToolsGatesAnnotations(t *testing.T) {
+	tests := []struct {
+		version         string
+		wantAnnotations bool
+		wantTitle       bool
+	}{
+		{"2024-11-05", false, false},
+		{"2025-03-26", true, false},
+		{"2025-06-18", true, true},
+	}
+
+	for _, tt := range tests {
+		server := newTestServer(t)
+		ctx := context.Background()
+		initializeSession(t, server, ctx, tt.version)
+
+		response := server.HandleRequest(ctx, Request{JSONRPC: "2.0", ID: 2, Method: "tools/list"})
+		tools := response.Result.(ListToolsResult).Tools
+
+		for _, tool := range tools {
+			if (tool.Annotations != nil) != tt.wantAnnotations {
+				t.Errorf("%s: unexpected annotations on %s: %+v", tt.version, tool.Name, tool.Annotations)
+			}
+			if (tool.Title != "") != tt.wantTitle {
+				t.Errorf("%s: unexpected title on %s: %q", tt.version, tool.Name, tool.Title)
+			}
+			if tool.Annotations == nil {
+				continue
+			}
+			readOnly := *tool.Annotations.ReadOnlyHint
+			if tool.Name == "terraform_rename" && readOnly {
+				t.Errorf("%s: expected terraform_rename not to be read-only", tt.version)
+			}
+			if tool.Name == "terraform_hover" && !readOnly {
+				t.Errorf("%s: expected terraform_hover to be read-only", tt.version)
+			}
+		}
+	}
+}
+
+func TestResponseForFeatures(t *testing.T) {
+	response := structuredResult(1, map[string]interface{}{"blocks": []string{}}, "text")
+
+	kept := responseForFeatures(response, featuresFor(ProtocolVersion20250618)).Result.(CallToolResult)
+	if kept.StructuredContent == nil {
+		t.Error("Expected structured content for 2025-06-18")
+	}
+
+	stripped := responseForFeatures(response, featuresFor(ProtocolVersion20250326)).Result.(CallToolResult)
+	if stripped.StructuredContent != nil {
+		t.Error("Expected no structured content for 2025-03-26")
+	}
+	if len(stripped.Content) != 1 || stripped.Content[0].Text != "text" {
+		t.Errorf("Expected the text content to be kept, got: %+v", stripped.Content)
+	}
+}
+
+func TestServer_ListToolsGatesOutputSchemas(t *testing.T) {
+	for version, want := range map[string]bool{ProtocolVersion20250326: false, ProtocolVersion20250618: true} {
+		server := newTestServer(t)
+		ctx := context.Background()
+		initializeSession(t, server, ctx, version)
+
+		response := server.HandleRequest(ctx, Request{JSONRPC: "2.0", ID: 2, Method: "tools/list"})
+		for _, tool := range response.Result.(ListToolsResult).Tools {
+			_, structured := toolOutputSchemas[tool.Name]
+			if got := tool.OutputSchema != nil; got != (want && structured) {
+				t.Errorf("%s: unexpected output schema on %s: %v", version, tool.Name, tool.OutputSchema)
+			}
+		}
+	}
+
+	structured := structuredTools(t)
+	if len(structured) < 7 {
+		t.Fatalf("Expected to find the tools returning structured content, got: %v", structured)
+	}
+	for _, name := range structured {
+		if toolOutputSchemas[name]["type"] != "object" {
+			t.Errorf("Expected an object output schema for %s, which returns structured content", name)
+		}
+	}
+}
+
+// structuredTools returns the tools whose handlers return structured
+// content, found by reading the package source: callTool maps tool names to
+// handlers, and those handlers call structuredResult.
+func structuredTools(t *testing.T) []string {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse package: %v", err)
+	}
+
+	structuredHandlers := make(map[string]bool)
+	handlers := make(map[string]string)
+	for _, file := range pkgs["mcp"].Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "structuredResult" {
+						structuredHandlers[fn.Name.Name] = true
+					}
+				}
+
+				clause, ok := n.(*ast.CaseClause)
+				if !ok || fn.Name.Name != "callTool" || len(clause.List) != 1 || len(clause.Body) != 1 {
+					return true
+				}
+				name, ok := clause.List[0].(*ast.BasicLit)
+				ret, isReturn := clause.Body[0].(*ast.ReturnStmt)
+				if !ok || !isReturn || len(ret.Results) != 1 {
+					return true
+				}
+				if call, ok := ret.Results[0].(*ast.CallExpr); ok {
+					if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+						handlers[strings.Trim(name.Value, `"`)] = sel.Sel.Name
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	var tools []string
+	for name, handler := range handlers {
+		if structuredHandlers[handler] {
+			tools = append(tools, name)
+		}
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+func TestServer_CallToolMeta(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	initializeSession(t, server, ctx, ProtocolVersion20250618)
+
+	// Request _meta is accepted; the tool itself rejects the arguments
+	response := server.HandleRequest(ctx, Request{
+		JSONRPC: "2.0",
+		ID:      2,
+		Method:  "tools/call",
+		Params:  json.RawMessage(`{"name": "terraform_format", "arguments": {}, "_meta": {"progressToken": 7, "traceId": "abc"}}`),
+	})
+	if response.Error == nil || response.Error.Message == "Invalid params" {
+		t.Fatalf("Expected the request to reach the tool, got: %+v", response.Error)
+	}
+
+	result := textResult(1, "text")
+	callResult := result.Result.(CallToolResult)
+	callResult.Meta = map[string]interface{}{"example": true}
+	result.Result = callResult
+
+	if kept := responseForFeatures(result, featuresFor(ProtocolVersion20250618)).Result.(CallToolResult); kept.Meta == nil {
+		t.Error("Expected result _meta for 2025-06-18")
+	}
+	if stripped := responseForFeatures(result, featuresFor(ProtocolVersion20250326)).Result.(CallToolResult); stripped.Meta != nil {
+		t.Errorf("Expected no result _meta for 2025-03-26, got: %v", stripped.Meta)
+	}
+}
+
+func TestServer_HandleNotifications(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	if response := server.HandleRequest(ctx, Request{JSONRPC: "2.0", Method: "notifications/initialized"}); response != nil {
+		t.Errorf("Expected no response to a notification, got: %+v", response)
+	}
+	if !server.session.Initialized() {
+		t.Error("Expected the session to be initialized")
+	}
+
+	if response := server.HandleRequest(ctx, Request{JSONRPC: "2.0", Method: "notifications/unknown"}); response != nil {
+		t.Errorf("Expected no response to an unknown notification, got: %+v", response)
+	}
+
+	response := server.HandleRequest(ctx, Request{JSONRPC: "2.0", ID: "ping-1", Method: "ping"})
+	if response == nil || response.Error != nil || response.ID != "ping-1" {
+		t.Fatalf("Expected an empty result for ping, got: %+v", response)
+	}
+	if encoded, _ := json.Marshal(response.Result); string(encoded) != "{}" {
+		t.Errorf("Expected an empty result for ping, got: %s", encoded)
+	}
+}
+
+func TestServer_CancelledNotificationAbortsRequest(t *testing.T) {
+	server := newTestServer(t)
+	session := NewSession()
+	ctx := WithSession(context.Background(), session)
+
+	// A request with a JSON number ID, as decoded from the wire
+	requestCtx, finish := session.begin(ctx, float64(7))
+	other, finishOther := session.begin(ctx, "7")
+	defer finishOther()
+
+	response := server.HandleRequest(ctx, Request{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  json.RawMessage(`{"requestId": 7, "reason": "user cancelled"}`),
+	})
+	if response != nil {
+		t.Errorf("Expected no response to a notification, got: %+v", response)
+	}
+
+	select {
+	case <-requestCtx.Done():
+	default:
+		t.Error("Expected the request context to be cancelled")
+	}
+	if other.Err() != nil {
+		t.Error("Expected the request with the string ID to keep running")
+	}
+	if !finish() {
+		t.Error("Expected the request to be reported as cancelled")
+	}
+
+	// Cancelling a finished request is ignored
+	session.cancelRequest(float64(7))
+}
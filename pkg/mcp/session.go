@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Session is the state the server keeps for one MCP client, such as the
+// protocol revision agreed on during initialize. Transports that serve
+// several clients attach a session per client to the request context with
+// WithSession; requests without one share the server's default session.
+type Session struct {
+	mu              sync.Mutex
+	protocolVersion string
+	initialized     bool
+
+	// inFlight holds the requests being handled, by requestKey of their ID
+	inFlight map[string]*inFlightRequest
+}
+
+// inFlightRequest is a request that the client may cancel
+type inFlightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// NewSession creates the state of a new client
+func NewSession() *Session {
+	return &Session{
+		inFlight: make(map[string]*inFlightRequest),
+	}
+}
+
+// ProtocolVersion returns the negotiated protocol revision. Before the
+// client has initialized, the oldest supported revision is assumed.
+func (s *Session) ProtocolVersion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.protocolVersion == "" {
+		return ProtocolVersion20241105
+	}
+	return s.protocolVersion
+}
+
+func (s *Session) setProtocolVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.protocolVersion = version
+}
+
+// Initialized reports whether the client has sent notifications/initialized
+func (s *Session) Initialized() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.initialized
+}
+
+func (s *Session) setInitialized() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initialized = true
+}
+
+// begin registers a request so that notifications/cancelled can abort it.
+// The returned context is cancelled when the client cancels the request;
+// finish must be called once the request is handled and reports whether
+// the client cancelled it.
+func (s *Session) begin(ctx context.Context, id interface{}) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	request := &inFlightRequest{cancel: cancel}
+	key := requestKey(id)
+
+	s.mu.Lock()
+	s.inFlight[key] = request
+	s.mu.Unlock()
+
+	return ctx, func() bool {
+		cancel()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.inFlight[key] == request {
+			delete(s.inFlight, key)
+		}
+		return request.cancelled
+	}
+}
+
+// cancelRequest aborts an in-flight request. Requests that already finished
+// or are unknown are ignored.
+func (s *Session) cancelRequest(id interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request := s.inFlight[requestKey(id)]; request != nil {
+		request.cancelled = true
+		request.cancel()
+	}
+}
+
+// requestKey identifies a request ID. IDs may be numbers or strings, and 1
+// and "1" are different requests.
+func requestKey(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+type sessionKey struct{}
+
+// WithSession returns a context whose requests are handled for session
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// sessionFor returns the session a request belongs to
+func (s *Server) sessionFor(ctx context.Context) *Session {
+	if session, ok := ctx.Value(sessionKey{}).(*Session); ok {
+		return session
+	}
+	return s.session
+}
@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+func signatureHelpTool() Tool {
+	return Tool{
+		Name:        "terraform_signature_help",
+		Description: "Show the signature of the Terraform function call at a position, such as inside cidrsubnet( or lookup(, with the documentation of each parameter and the parameter at the cursor",
+		InputSchema: documentToolSchema("Path to the specific Terraform file", positionProperties(), "line", "character"),
+	}
+}
+
+func (s *Server) handleSignatureHelpTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	line, character, err := positionArgs(args)
+	if err != nil {
+		return s.errorResponse(requestID, -32602, err.Error())
+	}
+
+	doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+	if errResp != nil {
+		return *errResp
+	}
+	defer doc.release()
+
+	help, err := doc.client.SignatureHelp(ctx, doc.uri, doc.content, line, character)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get signature help: %v", err))
+	}
+
+	if help == nil {
+		return textResult(requestID, fmt.Sprintf("No function call in %s at line %d, character %d.", doc.filePath, line, character))
+	}
+
+	return textResult(requestID, formatSignatureHelp(help))
+}
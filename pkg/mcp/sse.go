@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEQueueSize is the number of responses a legacy SSE session may
+// have pending, being handled or waiting to be written to its stream
+const DefaultSSEQueueSize = 64
+
+// sseSession is a client of the legacy HTTP+SSE transport. It lives as long
+// as its SSE connection.
+type sseSession struct {
+	session *Session
+
+	// ctx is cancelled when the SSE connection closes
+	ctx context.Context
+
+	// queue holds responses for the stream. A slot is taken for each request
+	// accepted and given back once its response leaves the queue, so the
+	// queue never fills up.
+	queue chan *Response
+	slots chan struct{}
+}
+
+// SSEHandler serves the HTTP+SSE transport of MCP 2024-11-05 for older
+// clients. A GET on the /sse endpoint opens a stream whose first event names
+// the endpoint to POST messages to, /messages?sessionId=<id>. POSTs are
+// accepted with 202 and their responses are sent as events on the stream.
+// Each stream is its own Session; clients that do not read their stream
+// fast enough get 503 once QueueSize responses are pending.
+type SSEHandler struct {
+	server *Server
+
+	// KeepAlive is how often idle streams get a comment
+	KeepAlive time.Duration
+
+	// QueueSize bounds the responses pending per session
+	QueueSize int
+
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+	done     chan struct{}
+	closed   bool
+}
+
+// NewSSEHandler creates a legacy HTTP+SSE handler for server. It serves
+// paths ending in /sse and /messages.
+func NewSSEHandler(server *Server) *SSEHandler {
+	return &SSEHandler{
+		server:    server,
+		KeepAlive: defaultKeepAlive,
+		QueueSize: DefaultSSEQueueSize,
+		sessions:  make(map[string]*sseSession),
+		done:      make(chan struct{}),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowedOrigin(r) {
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/sse") && r.Method == http.MethodGet:
+		h.handleStream(w, r)
+	case strings.HasSuffix(r.URL.Path, "/messages") && r.Method == http.MethodPost:
+		h.handleMessage(w, r)
+	case strings.HasSuffix(r.URL.Path, "/sse"):
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	case strings.HasSuffix(r.URL.Path, "/messages"):
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *SSEHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	queueSize := h.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultSSEQueueSize
+	}
+	client := &sseSession{
+		session: NewSession(),
+		ctx:     r.Context(),
+		queue:   make(chan *Response, queueSize),
+		slots:   make(chan struct{}, queueSize),
+	}
+
+	id := newSessionID()
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	h.sessions[id] = client
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.sessions, id)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// The messages endpoint sits next to the stream endpoint
+	endpoint := strings.TrimSuffix(r.URL.Path, "sse") + "messages?sessionId=" + id
+	if _, err := fmt.Fprintf(w, "event: endpoint\ndata: %s\n\n", endpoint); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case response := <-client.queue:
+			<-client.slots
+			if err := writeEvent(w, response); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *SSEHandler) handleMessage(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("sessionId")
+	if id == "" {
+		http.Error(w, "Missing sessionId", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	client := h.sessions[id]
+	h.mu.Unlock()
+	if client == nil {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return
+	}
+
+	var request Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid message: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx := WithSession(client.ctx, client.session)
+
+	// Notifications are handled right away, so that cancellations are not
+	// held up behind slow requests. Responses to server requests are ignored.
+	if request.IsNotification() || request.Method == "" {
+		if request.Method != "" {
+			h.server.HandleRequest(ctx, request)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	select {
+	case client.slots <- struct{}{}:
+	default:
+		http.Error(w, "Too many pending responses", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Register the request before answering, so that a cancellation sent
+	// right after the 202 finds it
+	requestCtx, finish := client.session.begin(ctx, request.ID)
+	w.WriteHeader(http.StatusAccepted)
+
+	go func() {
+		response := h.server.handleTracked(requestCtx, request, finish)
+		if response == nil {
+			<-client.slots
+			return
+		}
+		client.queue <- response
+	}()
+}
+
+// Close ends every stream
+func (h *SSEHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.closed {
+		h.closed = true
+		close(h.done)
+	}
+}
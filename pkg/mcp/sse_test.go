@@ -0,0 +1,226 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSSETestServer(t *testing.T, server *Server) (*httptest.Server, *SSEHandler) {
+	t.Helper()
+
+	handler := NewSSEHandler(server)
+	mux := http.NewServeMux()
+	mux.Handle("/sse", handler)
+	mux.Handle("/messages", handler)
+
+	httpServer := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		handler.Close()
+		httpServer.Close()
+	})
+	return httpServer, handler
+}
+
+// sseStream is an open legacy SSE connection
+type sseStream struct {
+	resp     *http.Response
+	reader   *bufio.Reader
+	endpoint string
+}
+
+// openSSEStream connects to /sse and reads the endpoint event
+func openSSEStream(t *testing.T, baseURL string) *sseStream {
+	t.Helper()
+
+	resp, err := http.Get(baseURL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	stream := &sseStream{resp: resp, reader: bufio.NewReader(resp.Body)}
+	event, data := stream.next(t)
+	if event != "endpoint" || !strings.HasPrefix(data, "/messages?sessionId=") {
+		t.Fatalf("Expected an endpoint event, got: %s %q", event, data)
+	}
+	stream.endpoint = baseURL + data
+	return stream
+}
+
+// next reads the next event, skipping comments
+func (s *sseStream) next(t *testing.T) (event, data string) {
+	t.Helper()
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && event != "":
+			return event, data
+		}
+	}
+}
+
+// response reads the next message event as a response
+func (s *sseStream) response(t *testing.T) Response {
+	t.Helper()
+
+	event, data := s.next(t)
+	if event != "message" {
+		t.Fatalf("Expected a message event, got: %s", event)
+	}
+
+	var response Response
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response
+}
+
+func TestSSE_SessionsNegotiateSeparately(t *testing.T) {
+	server, _ := newSSETestServer(t, newTestServer(t))
+
+	oldStream := openSSEStream(t, server.URL)
+	newStream := openSSEStream(t, server.URL)
+	if oldStream.endpoint == newStream.endpoint {
+		t.Fatal("Expected a new session per stream")
+	}
+
+	for stream, version := range map[*sseStream]string{oldStream: ProtocolVersion20241105, newStream: ProtocolVersion20250618} {
+		resp := postMessage(t, stream.endpoint, "", Request{
+			JSONRPC: "2.0",
+			ID:      1,
+			Method:  "initialize",
+			Params:  json.RawMessage(`{"protocolVersion": "` + version + `", "capabilities": {}}`),
+		}, nil)
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("Expected 202 for initialize, got: %d", resp.StatusCode)
+		}
+
+		response := stream.response(t)
+		result, _ := json.Marshal(response.Result)
+		var initialized InitializeResult
+		if err := json.Unmarshal(result, &initialized); err != nil {
+			t.Fatalf("Failed to decode initialize result: %v", err)
+		}
+		if initialized.ProtocolVersion != version {
+			t.Errorf("Expected protocol version %s, got: %s", version, initialized.ProtocolVersion)
+		}
+	}
+
+	// Notifications are acknowledged and get no event
+	resp := postMessage(t, newStream.endpoint, "", Request{JSONRPC: "2.0", Method: "notifications/initialized"}, nil)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for a notification, got: %d", resp.StatusCode)
+	}
+
+	for stream, wantAnnotations := range map[*sseStream]bool{oldStream: false, newStream: true} {
+		postMessage(t, stream.endpoint, "", Request{JSONRPC: "2.0", ID: 2, Method: "tools/list"}, nil)
+
+		response := stream.response(t)
+		if response.ID != float64(2) {
+			t.Fatalf("Expected the tools/list response, got ID: %v", response.ID)
+		}
+		result, _ := json.Marshal(response.Result)
+		var tools ListToolsResult
+		if err := json.Unmarshal(result, &tools); err != nil {
+			t.Fatalf("Failed to decode tools: %v", err)
+		}
+		if got := tools.Tools[0].Annotations != nil; got != wantAnnotations {
+			t.Errorf("Expected annotations %v, got: %v", wantAnnotations, got)
+		}
+	}
+}
+
+func TestSSE_RejectsBadRequests(t *testing.T) {
+	server, _ := newSSETestServer(t, newTestServer(t))
+	stream := openSSEStream(t, server.URL)
+	ping := Request{JSONRPC: "2.0", ID: 1, Method: "ping"}
+
+	tests := []struct {
+		name   string
+		url    string
+		header map[string]string
+		want   int
+	}{
+		{"missing session", server.URL + "/messages", nil, http.StatusBadRequest},
+		{"unknown session", server.URL + "/messages?sessionId=unknown", nil, http.StatusNotFound},
+		{"foreign origin", stream.endpoint, map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"valid", stream.endpoint, nil, http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		if resp := postMessage(t, tt.url, "", ping, tt.header); resp.StatusCode != tt.want {
+			t.Errorf("%s: expected %d, got: %d", tt.name, tt.want, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(stream.endpoint)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET on messages, got: %d", resp.StatusCode)
+	}
+
+	// The session ends with its stream
+	stream.resp.Body.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := postMessage(t, stream.endpoint, "", ping, nil)
+		if resp.StatusCode == http.StatusNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 404 after the stream closed, got: %d", resp.StatusCode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSSE_BoundsPendingResponses(t *testing.T) {
+	mcpServer, started, release := blockingServer(t)
+	server, handler := newSSETestServer(t, mcpServer)
+	handler.QueueSize = 1
+	stream := openSSEStream(t, server.URL)
+
+	if resp := postMessage(t, stream.endpoint, "", validateCall(1, "/workspace"), nil); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected 202 for the first request, got: %d", resp.StatusCode)
+	}
+	<-started
+
+	// The only slot is taken until the first response is sent
+	ping := Request{JSONRPC: "2.0", ID: 2, Method: "ping"}
+	if resp := postMessage(t, stream.endpoint, "", ping, nil); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while a response is pending, got: %d", resp.StatusCode)
+	}
+
+	close(release)
+	if response := stream.response(t); response.ID != float64(1) {
+		t.Errorf("Expected the response to request 1, got: %v", response.ID)
+	}
+
+	if resp := postMessage(t, stream.endpoint, "", ping, nil); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected 202 once the response was sent, got: %d", resp.StatusCode)
+	}
+	if response := stream.response(t); response.ID != float64(2) {
+		t.Errorf("Expected the response to request 2, got: %v", response.ID)
+	}
+}
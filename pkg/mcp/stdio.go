@@ -0,0 +1,131 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Defaults for Serve
+const (
+	// DefaultWorkers is the number of requests handled at once
+	DefaultWorkers = 8
+
+	// queuedRequestsPerWorker bounds how many requests wait for a worker
+	// before reading more input blocks
+	queuedRequestsPerWorker = 8
+)
+
+// queuedRequest is a request read from the stream that waits for a worker.
+// It is registered with its session when read, so that the client can
+// cancel it before it starts.
+type queuedRequest struct {
+	ctx     context.Context
+	request Request
+	finish  func() bool
+}
+
+// messageWriter serializes messages written by concurrent workers
+type messageWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+func (w *messageWriter) write(response *Response) {
+	if response == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return
+	}
+	w.err = w.encoder.Encode(response)
+}
+
+func (w *messageWriter) error() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+// Serve reads newline delimited MCP messages from in and writes the
+// responses to out until in is exhausted or ctx is done. Up to workers
+// requests are handled at once, so responses may be written in a different
+// order than the requests arrived. Notifications and ping are handled as
+// soon as they are read, so that a client can cancel a request or check the
+// server while every worker is busy.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer, workers int) error {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := &messageWriter{encoder: json.NewEncoder(out)}
+	queue := make(chan queuedRequest, workers*queuedRequestsPerWorker)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for queued := range queue {
+				// Requests cancelled while queued get no response
+				if queued.ctx.Err() != nil {
+					queued.finish()
+					continue
+				}
+				writer.write(s.handleTracked(queued.ctx, queued.request, queued.finish))
+			}
+		}()
+	}
+
+	err := s.readRequests(ctx, json.NewDecoder(in), writer, queue)
+
+	// Let the requests already read finish and write their responses
+	close(queue)
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	return writer.error()
+}
+
+// readRequests decodes messages and queues requests for the workers until
+// the input ends
+func (s *Server) readRequests(ctx context.Context, decoder *json.Decoder, writer *messageWriter, queue chan<- queuedRequest) error {
+	for {
+		var request Request
+		if err := decoder.Decode(&request); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := writer.error(); err != nil {
+			return err
+		}
+
+		if request.IsNotification() || request.Method == "ping" {
+			writer.write(s.HandleRequest(ctx, request))
+			continue
+		}
+
+		requestCtx, finish := s.sessionFor(ctx).begin(ctx, request.ID)
+		select {
+		case queue <- queuedRequest{ctx: requestCtx, request: request, finish: finish}:
+		case <-ctx.Done():
+			finish()
+			return ctx.Err()
+		}
+	}
+}
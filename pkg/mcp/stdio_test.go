@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+// blockingServer returns a server whose terraform-ls sessions fail to start
+// once release is closed. started is closed when the first session starts.
+func blockingServer(t *testing.T) (server *Server, started, release chan struct{}) {
+	t.Helper()
+
+	started, release = make(chan struct{}), make(chan struct{})
+	var once sync.Once
+
+	sessions := terraform.NewSessionManager(terraform.SessionOptions{
+		NewClient: func() (*terraform.Client, error) {
+			once.Do(func() { close(started) })
+			<-release
+			return nil, errors.New("terraform-ls is not available")
+		},
+	})
+	t.Cleanup(func() { sessions.Close() })
+
+	return NewServer(sessions), started, release
+}
+
+// serveStream runs Serve on pipes and returns an encoder for requests, a
+// decoder for responses, and a channel receiving the result of Serve
+func serveStream(t *testing.T, server *Server, workers int) (*json.Encoder, *json.Decoder, func(), <-chan error) {
+	t.Helper()
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		err := server.Serve(context.Background(), inReader, outWriter, workers)
+		outWriter.Close()
+		done <- err
+	}()
+
+	return json.NewEncoder(inWriter), json.NewDecoder(outReader), func() { inWriter.Close() }, done
+}
+
+func validateCall(id interface{}, workspace string) Request {
+	params, _ := json.Marshal(CallToolParams{
+		Name: "terraform_validate",
+		Arguments: map[string]interface{}{
+			"workspace_path": workspace,
+			"file_path":      workspace + "/main.tf",
+			"content":        "",
+		},
+	})
+	return Request{JSONRPC: "2.0", ID: id, Method: "tools/call", Params: params}
+}
+
+func readResponse(t *testing.T, decoder *json.Decoder) Response {
+	t.Helper()
+
+	var response Response
+	if err := decoder.Decode(&response); err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return response
+}
+
+func TestServer_ServeAnswersPingWhileBusy(t *testing.T) {
+	server, started, release := blockingServer(t)
+	requests, responses, closeInput, done := serveStream(t, server, 1)
+
+	requests.Encode(validateCall(1, t.TempDir()))
+	<-started
+
+	requests.Encode(Request{JSONRPC: "2.0", ID: 2, Method: "ping"})
+	if response := readResponse(t, responses); response.ID != float64(2) || response.Error != nil {
+		t.Fatalf("Expected the ping response first, got: %+v", response)
+	}
+
+	close(release)
+	if response := readResponse(t, responses); response.ID != float64(1) || response.Error == nil {
+		t.Errorf("Expected an error response for the validate call, got: %+v", response)
+	}
+
+	closeInput()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Serve to end cleanly, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after the input was closed")
+	}
+}
+
+func TestServer_ServeDropsCancelledQueuedRequest(t *testing.T) {
+	server, started, release := blockingServer(t)
+	requests, responses, closeInput, done := serveStream(t, server, 1)
+
+	requests.Encode(validateCall(1, t.TempDir()))
+	<-started
+
+	// The only worker is busy, so this request waits in the queue
+	requests.Encode(validateCall(2, t.TempDir()))
+	requests.Encode(Request{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  json.RawMessage(`{"requestId": 2}`),
+	})
+	requests.Encode(Request{JSONRPC: "2.0", ID: 3, Method: "ping"})
+	if response := readResponse(t, responses); response.ID != float64(3) {
+		t.Fatalf("Expected the ping response, got: %+v", response)
+	}
+
+	close(release)
+	if response := readResponse(t, responses); response.ID != float64(1) {
+		t.Errorf("Expected the response to the first request, got: %+v", response)
+	}
+
+	closeInput()
+	if err := <-done; err != nil {
+		t.Errorf("Expected Serve to end cleanly, got: %v", err)
+	}
+
+	var extra Response
+	if err := responses.Decode(&extra); err != io.EOF {
+		t.Errorf("Expected no response to the cancelled request, got: %+v (%v)", extra, err)
+	}
+}
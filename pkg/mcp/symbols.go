@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultSymbolDepth is how many levels of the outline are shown when the
+// caller does not set a depth: top-level blocks and their direct children
+const defaultSymbolDepth = 2
+
+func symbolsTool() Tool {
+	return Tool{
+		Name:        "terraform_symbols",
+		Description: "Get an outline of Terraform blocks (resources, data sources, variables, outputs, locals, module calls) with their ranges. Outlines a single file when file_path and content are given, otherwise every file in the workspace",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"workspace_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path to the Terraform workspace directory",
+				},
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path to a Terraform file to outline instead of the whole workspace",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "Content of the Terraform file; required with file_path",
+				},
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Only return workspace symbols matching this query",
+				},
+				"depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of outline levels to show (default: 2)",
+				},
+			},
+			"required": []string{"workspace_path"},
+		},
+	}
+}
+
+func (s *Server) handleSymbolsTool(ctx context.Context, requestID interface{}, args map[string]interface{}) Response {
+	depth := defaultSymbolDepth
+	if value, ok := args["depth"].(float64); ok {
+		if value < 1 {
+			return s.errorResponse(requestID, -32602, "depth must be at least 1")
+		}
+		depth = int(value)
+	}
+
+	if _, ok := args["file_path"]; ok {
+		doc, errResp := s.openDocumentRequest(ctx, requestID, args)
+		if errResp != nil {
+			return *errResp
+		}
+		defer doc.release()
+
+		symbols, err := doc.client.DocumentSymbols(ctx, doc.uri, doc.content)
+		if err != nil {
+			return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get document symbols: %v", err))
+		}
+
+		return textResult(requestID, formatDocumentSymbols(doc.filePath, symbols, depth))
+	}
+
+	workspacePath, ok := args["workspace_path"].(string)
+	if !ok {
+		return s.errorResponse(requestID, -32602, "workspace_path is required and must be a string")
+	}
+	query, _ := args["query"].(string)
+
+	tfClient, release, errResp := s.acquireWorkspace(ctx, requestID, workspacePath)
+	if errResp != nil {
+		return *errResp
+	}
+	defer release()
+
+	symbols, err := tfClient.WorkspaceSymbols(ctx, query)
+	if err != nil {
+		return s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get workspace symbols: %v", err))
+	}
+
+	return textResult(requestID, formatWorkspaceSymbols(workspacePath, query, symbols, depth))
+}
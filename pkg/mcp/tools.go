@@ -0,0 +1,301 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/terraform"
+)
+
+// documentRequest holds the arguments shared by tools that operate on a
+// single Terraform file, together with the terraform-ls session for its
+// workspace
+type documentRequest struct {
+	workspacePath string
+	filePath      string
+	content       string
+	uri           string
+	client        *terraform.Client
+	release       func()
+}
+
+// openDocumentRequest parses the workspace_path, file_path and content
+// arguments, acquires the session for the workspace and locks the document
+// in it. The caller must call release on the returned request.
+func (s *Server) openDocumentRequest(ctx context.Context, requestID interface{}, args map[string]interface{}) (*documentRequest, *Response) {
+	workspacePath, ok := args["workspace_path"].(string)
+	if !ok {
+		resp := s.errorResponse(requestID, -32602, "workspace_path is required and must be a string")
+		return nil, &resp
+	}
+
+	filePath, ok := args["file_path"].(string)
+	if !ok {
+		resp := s.errorResponse(requestID, -32602, "file_path is required and must be a string")
+		return nil, &resp
+	}
+
+	content, ok := args["content"].(string)
+	if !ok {
+		resp := s.errorResponse(requestID, -32602, "content is required and must be a string")
+		return nil, &resp
+	}
+
+	// Create file URI
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		resp := s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to get absolute path: %v", err))
+		return nil, &resp
+	}
+
+	tfClient, release, errResp := s.acquireWorkspace(ctx, requestID, workspacePath)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	// Hold the document until the request is done, so that a concurrent
+	// call for the same file cannot sync other content in between
+	uri := terraform.PathToURI(absPath)
+	unlock, err := tfClient.LockDocument(ctx, uri)
+	if err != nil {
+		release()
+		resp := s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to lock document: %v", err))
+		return nil, &resp
+	}
+
+	return &documentRequest{
+		workspacePath: workspacePath,
+		filePath:      filePath,
+		content:       content,
+		uri:           uri,
+		client:        tfClient,
+		release: func() {
+			unlock()
+			release()
+		},
+	}, nil
+}
+
+// acquireWorkspace returns the terraform-ls session for a workspace. The
+// caller must call the returned release function.
+func (s *Server) acquireWorkspace(ctx context.Context, requestID interface{}, workspacePath string) (*terraform.Client, func(), *Response) {
+	tfClient, release, err := s.sessions.Acquire(ctx, workspacePath)
+	if err != nil {
+		resp := s.errorResponse(requestID, -32603, fmt.Sprintf("Failed to initialize terraform-ls: %v", err))
+		return nil, nil, &resp
+	}
+	return tfClient, release, nil
+}
+
+// positionArgs parses the line and character arguments
+func positionArgs(args map[string]interface{}) (line, character int, err error) {
+	lineFloat, ok := args["line"].(float64)
+	if !ok {
+		return 0, 0, fmt.Errorf("line is required and must be a number")
+	}
+
+	characterFloat, ok := args["character"].(float64)
+	if !ok {
+		return 0, 0, fmt.Errorf("character is required and must be a number")
+	}
+
+	return int(lineFloat), int(characterFloat), nil
+}
+
+// rangeArgs parses the optional start_line, start_character, end_line and
+// end_character arguments. It returns nil if no range was given.
+func rangeArgs(args map[string]interface{}) (*terraform.Range, error) {
+	names := []string{"start_line", "start_character", "end_line", "end_character"}
+
+	values := make([]int, len(names))
+	given := 0
+	for i, name := range names {
+		if _, exists := args[name]; !exists {
+			continue
+		}
+		value, ok := args[name].(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a number", name)
+		}
+		values[i] = int(value)
+		given++
+	}
+
+	switch given {
+	case 0:
+		return nil, nil
+	case len(names):
+	default:
+		return nil, fmt.Errorf("start_line, start_character, end_line and end_character must be given together")
+	}
+
+	return &terraform.Range{
+		Start: terraform.Position{Line: values[0], Character: values[1]},
+		End:   terraform.Position{Line: values[2], Character: values[3]},
+	}, nil
+}
+
+// formattingOptionsArgs reads the optional formatting options of
+// terraform_format, starting from the defaults
+func formattingOptionsArgs(args map[string]interface{}) (terraform.FormattingOptions, error) {
+	opts := terraform.DefaultFormattingOptions()
+
+	if value, exists := args["tab_size"]; exists {
+		tabSize, ok := value.(float64)
+		if !ok || tabSize < 1 {
+			return opts, fmt.Errorf("tab_size must be a positive number")
+		}
+		opts.TabSize = int(tabSize)
+	}
+
+	flags := map[string]*bool{
+		"insert_spaces":            &opts.InsertSpaces,
+		"trim_trailing_whitespace": &opts.TrimTrailingWhitespace,
+		"insert_final_newline":     &opts.InsertFinalNewline,
+		"trim_final_newlines":      &opts.TrimFinalNewlines,
+	}
+	for name, flag := range flags {
+		value, exists := args[name]
+		if !exists {
+			continue
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return opts, fmt.Errorf("%s must be a boolean", name)
+		}
+		*flag = b
+	}
+
+	return opts, nil
+}
+
+// textResult returns a successful tool result with one text content item
+// per text
+func textResult(requestID interface{}, texts ...string) Response {
+	content := make([]Content, len(texts))
+	for i, text := range texts {
+		content[i] = Content{
+			Type: "text",
+			Text: text,
+		}
+	}
+
+	return Response{
+		JSONRPC: "2.0",
+		ID:      requestID,
+		Result: CallToolResult{
+			Content: content,
+		},
+	}
+}
+
+// structuredResult returns a successful tool result with text content, and
+// the same result as structured content for clients that support it.
+// structured must encode as a JSON object.
+func structuredResult(requestID interface{}, structured interface{}, texts ...string) Response {
+	response := textResult(requestID, texts...)
+	result := response.Result.(CallToolResult)
+	result.StructuredContent = structured
+	response.Result = result
+	return response
+}
+
+// documentToolSchema returns the input schema of a tool that operates on a
+// single Terraform file. extra adds tool specific properties, and required
+// lists which of them are required in addition to the document arguments.
+func documentToolSchema(fileDescription string, extra map[string]interface{}, required ...string) map[string]interface{} {
+	properties := map[string]interface{}{
+		"workspace_path": map[string]interface{}{
+			"type":        "string",
+			"description": "Path to the Terraform workspace directory",
+		},
+		"file_path": map[string]interface{}{
+			"type":        "string",
+			"description": fileDescription,
+		},
+		"content": map[string]interface{}{
+			"type":        "string",
+			"description": "Content of the Terraform file",
+		},
+	}
+	for name, property := range extra {
+		properties[name] = property
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   append([]string{"workspace_path", "file_path", "content"}, required...),
+	}
+}
+
+// positionProperties returns the schema properties for a cursor position
+func positionProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"line": map[string]interface{}{
+			"type":        "integer",
+			"description": "Line number (0-based)",
+		},
+		"character": map[string]interface{}{
+			"type":        "integer",
+			"description": "Character position (0-based)",
+		},
+	}
+}
+
+// rangeProperties returns the schema properties for an optional range
+func rangeProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"start_line": map[string]interface{}{
+			"type":        "integer",
+			"description": "Start line of the range (0-based)",
+		},
+		"start_character": map[string]interface{}{
+			"type":        "integer",
+			"description": "Start character of the range (0-based)",
+		},
+		"end_line": map[string]interface{}{
+			"type":        "integer",
+			"description": "End line of the range (0-based)",
+		},
+		"end_character": map[string]interface{}{
+			"type":        "integer",
+			"description": "End character of the range (0-based, exclusive)",
+		},
+	}
+}
+
+// formatProperties returns the schema properties of terraform_format: an
+// optional range or typed character, and the formatting options
+func formatProperties() map[string]interface{} {
+	properties := rangeProperties()
+	properties["typed_character"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Character just typed, such as } or a newline, to format as an editor does on typing it. Requires line and character, the position after the typed character",
+	}
+	for name, property := range positionProperties() {
+		properties[name] = property
+	}
+	properties["tab_size"] = map[string]interface{}{
+		"type":        "integer",
+		"description": "Size of a tab in spaces (default: 2)",
+	}
+	properties["insert_spaces"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Prefer spaces over tabs (default: true)",
+	}
+	properties["trim_trailing_whitespace"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Trim trailing whitespace on each line",
+	}
+	properties["insert_final_newline"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Insert a newline at the end of the file if there is none",
+	}
+	properties["trim_final_newlines"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Trim all newlines after the final newline at the end of the file",
+	}
+	return properties
+}
@@ -0,0 +1,46 @@
+package mcp
+
+import "testing"
+
+func TestRangeArgs(t *testing.T) {
+	r, err := rangeArgs(map[string]interface{}{})
+	if err != nil || r != nil {
+		t.Errorf("Expected no range, got: %+v, %v", r, err)
+	}
+
+	r, err = rangeArgs(map[string]interface{}{
+		"start_line": float64(1), "start_character": float64(2),
+		"end_line": float64(3), "end_character": float64(4),
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse range: %v", err)
+	}
+	if r.Start.Line != 1 || r.Start.Character != 2 || r.End.Line != 3 || r.End.Character != 4 {
+		t.Errorf("Unexpected range: %+v", r)
+	}
+
+	if _, err := rangeArgs(map[string]interface{}{"start_line": float64(1)}); err == nil {
+		t.Error("Expected an error for a partial range")
+	}
+}
+
+func TestFormattingOptionsArgs(t *testing.T) {
+	opts, err := formattingOptionsArgs(map[string]interface{}{})
+	if err != nil || opts.TabSize != 2 || !opts.InsertSpaces || opts.TrimTrailingWhitespace {
+		t.Errorf("Expected default options, got: %+v, %v", opts, err)
+	}
+
+	opts, err = formattingOptionsArgs(map[string]interface{}{
+		"tab_size": float64(4), "trim_trailing_whitespace": true, "insert_final_newline": true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse options: %v", err)
+	}
+	if opts.TabSize != 4 || !opts.TrimTrailingWhitespace || !opts.InsertFinalNewline || opts.TrimFinalNewlines {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	if _, err := formattingOptionsArgs(map[string]interface{}{"trim_final_newlines": "yes"}); err == nil {
+		t.Error("Expected an error for a non-boolean option")
+	}
+}
@@ -14,6 +14,12 @@ type Request struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 }
 
+// IsNotification reports whether the message is a notification, which has
+// no ID and must not be answered
+func (r Request) IsNotification() bool {
+	return r.ID == nil
+}
+
 // Response represents an MCP response
 type Response struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -29,6 +35,13 @@ type Error struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// CancelledNotificationParams represents parameters for
+// notifications/cancelled
+type CancelledNotificationParams struct {
+	RequestID interface{} `json:"requestId"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
 // InitializeParams represents initialization parameters
 type InitializeParams struct {
 	ProtocolVersion string                 `json:"protocolVersion"`
@@ -69,11 +82,24 @@ type ServerInfo struct {
 	Version string `json:"version"`
 }
 
-// Tool represents a tool definition
+// Tool represents a tool definition. Title, OutputSchema and Annotations are
+// only sent to clients whose protocol revision supports them.
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
+	Name         string                 `json:"name"`
+	Title        string                 `json:"title,omitempty"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations describe the behaviour of a tool to the client
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
 }
 
 // ListToolsResult represents the result of tools/list
@@ -85,12 +111,16 @@ type ListToolsResult struct {
 type CallToolParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      map[string]interface{} `json:"_meta,omitempty"`
 }
 
-// CallToolResult represents the result of tools/call
+// CallToolResult represents the result of tools/call. StructuredContent and
+// Meta are only sent to clients whose protocol revision supports them.
 type CallToolResult struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	Content           []Content              `json:"content"`
+	StructuredContent interface{}            `json:"structuredContent,omitempty"`
+	IsError           bool                   `json:"isError,omitempty"`
+	Meta              map[string]interface{} `json:"_meta,omitempty"`
 }
 
 // Content represents content in a tool result
@@ -2,16 +2,35 @@ package terraform
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp"
 )
 
+// ErrAlreadyInitialized is returned when Initialize is called for a different
+// workspace on a client that has already been initialized. A terraform-ls
+// process serves a single workspace; use a SessionManager to work with
+// several.
+var ErrAlreadyInitialized = errors.New("terraform-ls client already initialized")
+
 // Client represents a terraform-ls client
 type Client struct {
-	lspClient *lsp.Client
-	workspaceRoot string
+	lspClient   *lsp.Client
+	diagnostics *diagnosticsStore
+	documents   *documentStore
+
+	mu                 sync.Mutex
+	workspaceRoot      string
+	settings           map[string]interface{}
+	initialized        bool
+	capabilities       ServerCapabilities
+	diagnosticsTimeout time.Duration
+	diagnosticsSettle  time.Duration
 }
 
 // NewClient creates a new terraform-ls client
@@ -20,12 +39,28 @@ func NewClient() (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create LSP client: %w", err)
 	}
-	
+
+	return NewClientWithLSP(lspClient), nil
+}
+
+// NewClientWithLSP creates a terraform-ls client on top of an existing LSP
+// connection and subscribes to the notifications it relies on
+func NewClientWithLSP(lspClient *lsp.Client) *Client {
 	client := &Client{
-		lspClient: lspClient,
+		lspClient:   lspClient,
+		diagnostics: newDiagnosticsStore(),
+		settings:    make(map[string]interface{}),
+		documents:   newDocumentStore(defaultMaxOpenDocuments),
+
+		diagnosticsTimeout: defaultDiagnosticsTimeout,
+		diagnosticsSettle:  defaultDiagnosticsSettle,
 	}
-	
-	return client, nil
+
+	lspClient.OnNotification("textDocument/publishDiagnostics", client.diagnostics.handlePublish)
+	lspClient.OnRequest("workspace/configuration", client.handleConfiguration)
+	lspClient.OnRequest("workspace/workspaceFolders", client.handleWorkspaceFolders)
+
+	return client
 }
 
 // Close closes the terraform-ls client
@@ -36,126 +71,287 @@ func (c *Client) Close() error {
 	return nil
 }
 
-// Initialize initializes the terraform-ls server with workspace
+// Shutdown asks terraform-ls to shut down cleanly with the shutdown request
+// and exit notification, then closes the client
+func (c *Client) Shutdown(ctx context.Context) error {
+	if c.lspClient == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	initialized := c.initialized
+	c.mu.Unlock()
+
+	if initialized {
+		if resp, err := c.lspClient.SendRequest(ctx, "shutdown", nil); err == nil && resp.Error == nil {
+			c.lspClient.SendNotification("exit", nil)
+		}
+	}
+
+	return c.Close()
+}
+
+// SetConfiguration sets the value returned to the server when it asks for
+// a configuration section through workspace/configuration
+func (c *Client) SetConfiguration(section string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings[section] = value
+}
+
+// SetDiagnosticsWait sets how long ValidateDocument waits for pushed
+// diagnostics, and the quiet period after a publish before it assumes the
+// server has finished validating
+func (c *Client) SetDiagnosticsWait(timeout, settle time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.diagnosticsTimeout = timeout
+	c.diagnosticsSettle = settle
+}
+
+// diagnosticsWait returns the bounds set with SetDiagnosticsWait
+func (c *Client) diagnosticsWait() (timeout, settle time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.diagnosticsTimeout, c.diagnosticsSettle
+}
+
+// Initialize initializes the terraform-ls server with workspace. Calling it
+// again for the same workspace is a no-op.
 func (c *Client) Initialize(ctx context.Context, workspaceRoot string) error {
-	c.workspaceRoot = workspaceRoot
-	
+	c.mu.Lock()
+	if c.initialized {
+		root := c.workspaceRoot
+		c.mu.Unlock()
+		if root == workspaceRoot {
+			return nil
+		}
+		return fmt.Errorf("%w for %s", ErrAlreadyInitialized, root)
+	}
+	c.mu.Unlock()
+
 	initParams := InitializeParams{
-		ProcessID:    nil,
-		RootURI:      fmt.Sprintf("file://%s", workspaceRoot),
+		ProcessID: nil,
+		RootURI:   PathToURI(workspaceRoot),
 		WorkspaceFolders: []WorkspaceFolder{
 			{
-				URI:  fmt.Sprintf("file://%s", workspaceRoot),
+				URI:  PathToURI(workspaceRoot),
 				Name: filepath.Base(workspaceRoot),
 			},
 		},
 		Capabilities: ClientCapabilities{
+			Workspace: &WorkspaceClientCapabilities{
+				Configuration:    true,
+				WorkspaceFolders: true,
+				WorkspaceEdit: &WorkspaceEditClientCapabilities{
+					DocumentChanges: true,
+				},
+			},
+			Window: &WindowClientCapabilities{
+				WorkDoneProgress: true,
+			},
+			Experimental: &ExperimentalClientCapabilities{
+				ShowReferencesCommandID: showReferencesCommandID,
+			},
 			TextDocument: &TextDocumentClientCapabilities{
 				Completion: &CompletionClientCapabilities{
 					CompletionItem: &CompletionItemClientCapabilities{
-						SnippetSupport: true,
+						SnippetSupport:      true,
+						DocumentationFormat: []string{"markdown", "plaintext"},
+						DeprecatedSupport:   true,
+						ResolveSupport: &ResolveSupport{
+							Properties: []string{"documentation", "detail"},
+						},
 					},
 				},
 				Hover: &HoverClientCapabilities{
 					ContentFormat: []string{"markdown", "plaintext"},
 				},
+				SignatureHelp: &SignatureHelpClientCapabilities{
+					SignatureInformation: &SignatureInformationClientCapabilities{
+						DocumentationFormat: []string{"markdown", "plaintext"},
+						ParameterInformation: &ParameterInformationClientCapabilities{
+							LabelOffsetSupport: true,
+						},
+						ActiveParameterSupport: true,
+					},
+				},
+				Definition: &DefinitionClientCapabilities{
+					LinkSupport: true,
+				},
+				Declaration: &DeclarationClientCapabilities{
+					LinkSupport: true,
+				},
+				DocumentSymbol: &DocumentSymbolClientCapabilities{
+					HierarchicalDocumentSymbolSupport: true,
+				},
+				Rename: &RenameClientCapabilities{
+					PrepareSupport: true,
+				},
+				CodeAction: &CodeActionClientCapabilities{
+					CodeActionLiteralSupport: &CodeActionLiteralSupport{
+						CodeActionKind: CodeActionKindValueSet{
+							ValueSet: []string{
+								CodeActionKindQuickFix,
+								CodeActionKindRefactor,
+								CodeActionKindSource,
+								CodeActionKindSourceFormatAll,
+							},
+						},
+					},
+					IsPreferredSupport: true,
+					DisabledSupport:    true,
+					DataSupport:        true,
+					ResolveSupport: &ResolveSupport{
+						Properties: []string{"edit"},
+					},
+				},
+				SemanticTokens: &SemanticTokensClientCapabilities{
+					Requests: SemanticTokensRequests{
+						Range: true,
+						Full:  true,
+					},
+					TokenTypes:     semanticTokenTypes,
+					TokenModifiers: semanticTokenModifiers,
+					Formats:        []string{"relative"},
+				},
+				FoldingRange:   &FoldingRangeClientCapabilities{},
+				SelectionRange: &SelectionRangeClientCapabilities{},
+				DocumentLink: &DocumentLinkClientCapabilities{
+					TooltipSupport: true,
+				},
 			},
 		},
 	}
-	
+
 	resp, err := c.lspClient.SendRequest(ctx, "initialize", initParams)
 	if err != nil {
 		return fmt.Errorf("failed to initialize: %w", err)
 	}
-	
+
 	if resp.Error != nil {
 		return fmt.Errorf("initialize error: %s", resp.Error.Message)
 	}
-	
+
+	var result InitializeResult
+	if err := resp.UnmarshalResult(&result); err != nil {
+		return fmt.Errorf("failed to decode initialize result: %w", err)
+	}
+
+	// The workspace is only recorded once the server has accepted it, so
+	// that a failed start leaves no root behind
+	c.mu.Lock()
+	c.workspaceRoot = workspaceRoot
+	c.capabilities = result.Capabilities
+	c.mu.Unlock()
+
 	// Send initialized notification
 	if err := c.lspClient.SendNotification("initialized", struct{}{}); err != nil {
 		return fmt.Errorf("failed to send initialized notification: %w", err)
 	}
-	
+
+	c.mu.Lock()
+	c.initialized = true
+	c.mu.Unlock()
+
 	return nil
 }
 
-// ValidateDocument validates a Terraform document
+// WorkspaceRoot returns the workspace the client was initialized with
+func (c *Client) WorkspaceRoot() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.workspaceRoot
+}
+
+// ValidateDocument validates a Terraform document. Diagnostics are pulled
+// with textDocument/diagnostic when the server supports it; otherwise the
+// diagnostics pushed after opening the document are collected, waiting a
+// bounded time for the server to finish validating.
 func (c *Client) ValidateDocument(ctx context.Context, uri, content string) (*ValidationResult, error) {
+	since := c.diagnostics.generation(uri)
+
 	// Open document
-	if err := c.openDocument(ctx, uri, content); err != nil {
+	changed, err := c.openDocument(ctx, uri, content)
+	if err != nil {
 		return nil, fmt.Errorf("failed to open document: %w", err)
 	}
-	
-	// Get diagnostics (validation results)
-	resp, err := c.lspClient.SendRequest(ctx, "textDocument/diagnostic", DiagnosticParams{
-		TextDocument: TextDocumentIdentifier{
-			URI: uri,
-		},
-	})
-	
+
+	result := &ValidationResult{
+		URI: uri,
+	}
+
+	report, err := c.pullDiagnostics(ctx, uri)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get diagnostics: %w", err)
+		return nil, err
 	}
-	
-	if resp.Error != nil {
-		return nil, fmt.Errorf("diagnostic error: %s", resp.Error.Message)
+
+	if report != nil {
+		result.Diagnostics = c.diagnostics.resolvePulled(uri, *report)
+		for relatedURI, related := range report.RelatedDocuments {
+			if result.RelatedDocuments == nil {
+				result.RelatedDocuments = make(map[string][]Diagnostic)
+			}
+			result.RelatedDocuments[relatedURI] = c.diagnostics.resolvePulled(relatedURI, related)
+		}
+		return result, nil
 	}
-	
-	var diagnostics []Diagnostic
-	if resp.Result != nil {
-		// Parse diagnostics from response
-		// This is a simplified version - actual implementation would need proper parsing
+
+	// An unchanged document is not revalidated, so the diagnostics already
+	// published for it are current
+	if changed || since == 0 {
+		timeout, settle := c.diagnosticsWait()
+		timedOut, err := c.diagnostics.wait(ctx, uri, since, timeout, settle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for diagnostics: %w", err)
+		}
+		result.TimedOut = timedOut
 	}
-	
-	return &ValidationResult{
-		URI:         uri,
-		Diagnostics: diagnostics,
-	}, nil
+
+	result.Diagnostics = c.diagnostics.get(uri)
+
+	return result, nil
 }
 
-// FormatDocument formats a Terraform document
-func (c *Client) FormatDocument(ctx context.Context, uri, content string) (*FormatResult, error) {
-	// Open document
-	if err := c.openDocument(ctx, uri, content); err != nil {
-		return nil, fmt.Errorf("failed to open document: %w", err)
-	}
-	
-	resp, err := c.lspClient.SendRequest(ctx, "textDocument/formatting", DocumentFormattingParams{
+// pullDiagnostics requests a diagnostic report for a document. It returns a
+// nil report if the server does not support pull diagnostics, as is the case
+// for terraform-ls, which only pushes them through publishDiagnostics.
+func (c *Client) pullDiagnostics(ctx context.Context, uri string) (*DocumentDiagnosticReport, error) {
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/diagnostic", DiagnosticParams{
 		TextDocument: TextDocumentIdentifier{
 			URI: uri,
 		},
-		Options: FormattingOptions{
-			TabSize:      2,
-			InsertSpaces: true,
-		},
+		PreviousResultID: c.diagnostics.previousResultID(uri),
 	})
-	
 	if err != nil {
-		return nil, fmt.Errorf("failed to format document: %w", err)
+		return nil, fmt.Errorf("failed to get diagnostics: %w", err)
 	}
-	
+
 	if resp.Error != nil {
-		return nil, fmt.Errorf("format error: %s", resp.Error.Message)
-	}
-	
-	// Parse text edits from response
-	var textEdits []TextEdit
-	// This is a simplified version - actual implementation would need proper parsing
-	
-	return &FormatResult{
-		URI:   uri,
-		Edits: textEdits,
-	}, nil
+		if resp.Error.Code == lsp.CodeMethodNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("diagnostic error: %s", resp.Error.Message)
+	}
+
+	var report DocumentDiagnosticReport
+	if err := resp.UnmarshalResult(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode diagnostic report: %w", err)
+	}
+
+	return &report, nil
 }
 
-// GetCompletion gets completion suggestions for a position in document
+// GetCompletion gets completion suggestions for a position in document,
+// ordered by their sort text
 func (c *Client) GetCompletion(ctx context.Context, uri, content string, line, character int) (*CompletionResult, error) {
 	// Open document
-	if err := c.openDocument(ctx, uri, content); err != nil {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
 		return nil, fmt.Errorf("failed to open document: %w", err)
 	}
-	
+
 	resp, err := c.lspClient.SendRequest(ctx, "textDocument/completion", CompletionParams{
 		TextDocument: TextDocumentIdentifier{
 			URI: uri,
@@ -165,34 +361,69 @@ func (c *Client) GetCompletion(ctx context.Context, uri, content string, line, c
 			Character: character,
 		},
 	})
-	
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to get completion: %w", err)
 	}
-	
+
 	if resp.Error != nil {
 		return nil, fmt.Errorf("completion error: %s", resp.Error.Message)
 	}
-	
-	// Parse completion items from response
-	var completionItems []CompletionItem
-	// This is a simplified version - actual implementation would need proper parsing
-	
+
+	var raw json.RawMessage
+	if err := resp.UnmarshalResult(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read completion result: %w", err)
+	}
+
+	list, err := decodeCompletionResult(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode completion items: %w", err)
+	}
+	sortCompletionItems(list.Items)
+
 	return &CompletionResult{
-		URI:   uri,
-		Items: completionItems,
+		URI:          uri,
+		Items:        list.Items,
+		IsIncomplete: list.IsIncomplete,
 	}, nil
 }
 
-func (c *Client) openDocument(ctx context.Context, uri, content string) error {
-	params := DidOpenTextDocumentParams{
-		TextDocument: TextDocumentItem{
-			URI:        uri,
-			LanguageID: "terraform",
-			Version:    1,
-			Text:       content,
+func (c *Client) handleConfiguration(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p lsp.ConfigurationParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &lsp.Error{Code: lsp.CodeInvalidParams, Message: err.Error()}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]interface{}, len(p.Items))
+	for i, item := range p.Items {
+		result[i] = c.settings[item.Section]
+	}
+	return result, nil
+}
+
+func (c *Client) handleWorkspaceFolders(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.workspaceRoot == "" {
+		return nil, nil
+	}
+	return []WorkspaceFolder{
+		{
+			URI:  PathToURI(c.workspaceRoot),
+			Name: filepath.Base(c.workspaceRoot),
 		},
+	}, nil
+}
+
+// PublishedDiagnostics returns the diagnostics most recently pushed by the
+// server for a document
+func (c *Client) PublishedDiagnostics(uri string) []Diagnostic {
+	if c.diagnostics == nil {
+		return nil
 	}
-	
-	return c.lspClient.SendNotification("textDocument/didOpen", params)
-}
\ No newline at end of file
+	return c.diagnostics.get(uri)
+}
@@ -0,0 +1,287 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp/lsptest"
+)
+
+// newTestClient returns a Client connected to a scripted fake terraform-ls
+func newTestClient(t *testing.T) (*lsptest.Server, *Client) {
+	t.Helper()
+
+	server := lsptest.NewServer()
+	t.Cleanup(func() { server.Close() })
+
+	return server, NewClientWithLSP(server.Client())
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// roundTrip completes a request to the fake server, which guarantees that
+// every notification sent before it has been received
+func roundTrip(t *testing.T, server *lsptest.Server, client *Client) {
+	t.Helper()
+
+	server.Handle("$/roundTrip", func(params json.RawMessage) (interface{}, error) { return nil, nil })
+	if _, err := client.lspClient.SendRequest(testContext(t), "$/roundTrip", nil); err != nil {
+		t.Fatalf("Failed to complete round trip: %v", err)
+	}
+}
+
+func TestClient_ValidateDocumentUsesPublishedDiagnostics(t *testing.T) {
+	server, client := newTestClient(t)
+	client.SetDiagnosticsWait(2*time.Second, 20*time.Millisecond)
+
+	server.Handle("textDocument/didOpen", func(params json.RawMessage) (interface{}, error) {
+		var p DidOpenTextDocumentParams
+		json.Unmarshal(params, &p)
+
+		server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI: p.TextDocument.URI,
+			Diagnostics: []Diagnostic{
+				{
+					Range:    Range{Start: Position{Line: 1, Character: 2}, End: Position{Line: 1, Character: 5}},
+					Severity: 1,
+					Source:   "HCL",
+					Message:  "Unsupported argument",
+				},
+			},
+		})
+		return nil, nil
+	})
+
+	uri := "file:///workspace/main.tf"
+	result, err := client.ValidateDocument(testContext(t), uri, "resource \"a\" \"b\" {\n  foo = 1\n}\n")
+	if err != nil {
+		t.Fatalf("Failed to validate document: %v", err)
+	}
+
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("Expected 1 diagnostic, got: %d", len(result.Diagnostics))
+	}
+	if result.Diagnostics[0].Message != "Unsupported argument" {
+		t.Errorf("Expected message 'Unsupported argument', got: %s", result.Diagnostics[0].Message)
+	}
+	if got := client.PublishedDiagnostics(uri); len(got) != 1 {
+		t.Errorf("Expected 1 published diagnostic, got: %d", len(got))
+	}
+}
+
+func TestClient_AnswersConfigurationRequests(t *testing.T) {
+	server, client := newTestClient(t)
+	client.SetConfiguration("terraform", map[string]interface{}{"validation": map[string]bool{"enableEnhancedValidation": true}})
+
+	reply, err := server.Call("workspace/configuration", map[string]interface{}{
+		"items": []map[string]string{{"section": "terraform"}, {"section": "unknown"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to call client: %v", err)
+	}
+	if reply.Error != nil {
+		t.Fatalf("Expected no error, got: %v", reply.Error)
+	}
+
+	want := `[{"validation":{"enableEnhancedValidation":true}},null]`
+	if string(reply.Result) != want {
+		t.Errorf("Expected %s, got: %s", want, reply.Result)
+	}
+}
+
+func TestClient_ValidateDocumentWaitsForLatestPublish(t *testing.T) {
+	server, client := newTestClient(t)
+	client.SetDiagnosticsWait(2*time.Second, 100*time.Millisecond)
+
+	uri := "file:///workspace/main.tf"
+	server.Handle("textDocument/didOpen", func(params json.RawMessage) (interface{}, error) {
+		// Parsing finishes first, then schema validation publishes again
+		server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{}})
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			server.Notify("textDocument/publishDiagnostics", json.RawMessage(`{
+				"uri": "file:///workspace/main.tf",
+				"diagnostics": [{
+					"range": {"start": {"line": 3, "character": 0}, "end": {"line": 3, "character": 8}},
+					"severity": 2,
+					"code": "deprecated_attr",
+					"codeDescription": {"href": "https://registry.terraform.io/docs"},
+					"source": "terraform",
+					"message": "Deprecated attribute",
+					"tags": [2],
+					"relatedInformation": [{
+						"location": {"uri": "file:///workspace/variables.tf", "range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 4}}},
+						"message": "declared here"
+					}]
+				}]
+			}`))
+		}()
+		return nil, nil
+	})
+
+	result, err := client.ValidateDocument(testContext(t), uri, "locals {}\n")
+	if err != nil {
+		t.Fatalf("Failed to validate document: %v", err)
+	}
+	if result.TimedOut {
+		t.Error("Expected diagnostics before the timeout")
+	}
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("Expected 1 diagnostic, got: %d", len(result.Diagnostics))
+	}
+
+	d := result.Diagnostics[0]
+	if d.Code != "deprecated_attr" {
+		t.Errorf("Expected code 'deprecated_attr', got: %v", d.Code)
+	}
+	if d.CodeDescription == nil || d.CodeDescription.Href != "https://registry.terraform.io/docs" {
+		t.Errorf("Expected code description, got: %+v", d.CodeDescription)
+	}
+	if len(d.Tags) != 1 || d.Tags[0] != DiagnosticTagDeprecated {
+		t.Errorf("Expected deprecated tag, got: %v", d.Tags)
+	}
+	if len(d.RelatedInformation) != 1 || d.RelatedInformation[0].Location.URI != "file:///workspace/variables.tf" {
+		t.Errorf("Expected related information, got: %+v", d.RelatedInformation)
+	}
+}
+
+func TestClient_ValidateDocumentTimesOut(t *testing.T) {
+	_, client := newTestClient(t)
+	client.SetDiagnosticsWait(50*time.Millisecond, 10*time.Millisecond)
+
+	// The wait may be changed while other calls validate
+	changed := make(chan struct{})
+	go func() {
+		client.SetDiagnosticsWait(50*time.Millisecond, 10*time.Millisecond)
+		close(changed)
+	}()
+	defer func() { <-changed }()
+
+	result, err := client.ValidateDocument(testContext(t), "file:///workspace/main.tf", "")
+	if err != nil {
+		t.Fatalf("Failed to validate document: %v", err)
+	}
+	if !result.TimedOut {
+		t.Error("Expected validation to time out without published diagnostics")
+	}
+}
+
+func TestClient_ValidateDocumentPullDiagnostics(t *testing.T) {
+	server, client := newTestClient(t)
+
+	uri := "file:///workspace/main.tf"
+	server.Handle("textDocument/diagnostic", func(params json.RawMessage) (interface{}, error) {
+		var p DiagnosticParams
+		json.Unmarshal(params, &p)
+
+		if p.PreviousResultID == "r1" {
+			return DocumentDiagnosticReport{Kind: DiagnosticReportUnchanged, ResultID: "r1"}, nil
+		}
+		return DocumentDiagnosticReport{
+			Kind:     DiagnosticReportFull,
+			ResultID: "r1",
+			Items:    []Diagnostic{{Severity: SeverityError, Message: "Missing required argument"}},
+			RelatedDocuments: map[string]DocumentDiagnosticReport{
+				"file:///workspace/outputs.tf": {
+					Kind:  DiagnosticReportFull,
+					Items: []Diagnostic{{Severity: SeverityWarning, Message: "Reference to undeclared resource"}},
+				},
+			},
+		}, nil
+	})
+
+	result, err := client.ValidateDocument(testContext(t), uri, "")
+	if err != nil {
+		t.Fatalf("Failed to validate document: %v", err)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Message != "Missing required argument" {
+		t.Errorf("Expected pulled diagnostic, got: %+v", result.Diagnostics)
+	}
+	if len(result.RelatedDocuments["file:///workspace/outputs.tf"]) != 1 {
+		t.Errorf("Expected related document diagnostics, got: %+v", result.RelatedDocuments)
+	}
+
+	// The second pull is answered with "unchanged" and reuses the cached items
+	result, err = client.ValidateDocument(testContext(t), uri, "")
+	if err != nil {
+		t.Fatalf("Failed to validate document: %v", err)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Message != "Missing required argument" {
+		t.Errorf("Expected cached diagnostic for unchanged report, got: %+v", result.Diagnostics)
+	}
+}
+
+func TestClient_FormatDocumentAppliesEdits(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/formatting", func(params json.RawMessage) (interface{}, error) {
+		return []TextEdit{
+			{Range: Range{Start: Position{Line: 1, Character: 0}, End: Position{Line: 1, Character: 1}}, NewText: "  "},
+			{Range: Range{Start: Position{Line: 1, Character: 4}, End: Position{Line: 1, Character: 5}}, NewText: " = "},
+		}, nil
+	})
+
+	result, err := client.FormatDocument(testContext(t), "file:///workspace/main.tf", "locals {\n\tfoo=1\n}\n", DefaultFormattingOptions())
+	if err != nil {
+		t.Fatalf("Failed to format document: %v", err)
+	}
+
+	if len(result.Edits) != 2 {
+		t.Errorf("Expected 2 edits, got: %d", len(result.Edits))
+	}
+	if want := "locals {\n  foo = 1\n}\n"; result.Formatted != want {
+		t.Errorf("Expected %q, got: %q", want, result.Formatted)
+	}
+}
+
+func TestClient_GetCompletionAndResolve(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/completion", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`{"isIncomplete": false, "items": [
+			{"label": "instance_type", "kind": 10, "sortText": "b", "data": {"id": 2}},
+			{"label": "ami", "kind": 10, "sortText": "a", "data": {"id": 1}, "documentation": {"kind": "plaintext", "value": "AMI"}}
+		]}`), nil
+	})
+	var sent map[string]interface{}
+	server.Handle("completionItem/resolve", func(params json.RawMessage) (interface{}, error) {
+		var item map[string]interface{}
+		json.Unmarshal(params, &item)
+		json.Unmarshal(params, &sent)
+		item["documentation"] = map[string]string{"kind": "markdown", "value": "docs for " + item["label"].(string)}
+		return item, nil
+	})
+
+	result, err := client.GetCompletion(testContext(t), "file:///workspace/main.tf", "resource \"aws_instance\" \"x\" {\n  \n}\n", 1, 2)
+	if err != nil {
+		t.Fatalf("Failed to get completion: %v", err)
+	}
+	if len(result.Items) != 2 || result.Items[0].Label != "ami" {
+		t.Fatalf("Expected items ordered by sortText, got: %+v", result.Items)
+	}
+
+	resolved, err := client.ResolveCompletionItem(testContext(t), result.Items[0])
+	if err != nil {
+		t.Fatalf("Failed to resolve completion item: %v", err)
+	}
+	if resolved.Documentation != "docs for ami" || resolved.DocumentationFormat != "markdown" {
+		t.Errorf("Expected resolved documentation, got: %q", resolved.Documentation)
+	}
+	if string(resolved.Data) != `{"id":1}` {
+		t.Errorf("Expected data to be preserved, got: %s", resolved.Data)
+	}
+
+	// The item goes back as the server sent it
+	if _, ok := sent["documentationFormat"]; ok {
+		t.Errorf("Expected no documentationFormat in the resolved item, got: %v", sent)
+	}
+	if doc, ok := sent["documentation"].(map[string]interface{}); !ok || doc["value"] != "AMI" {
+		t.Errorf("Expected the original MarkupContent, got: %v", sent["documentation"])
+	}
+}
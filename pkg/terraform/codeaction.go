@@ -0,0 +1,139 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CodeActions returns the actions the server offers for a range of a
+// document. The diagnostics known for the range are sent along so that the
+// server can offer quick fixes for them. When only is set, actions of other
+// kinds are dropped; a kind matches its sub-kinds, so "source" matches
+// "source.formatAll.terraform". The other open documents are synced from
+// disk first, so that edits to them match the files.
+func (c *Client) CodeActions(ctx context.Context, uri, content string, r Range, only []string) ([]CodeAction, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	if err := c.RefreshDocuments(ctx, uri); err != nil {
+		return nil, fmt.Errorf("failed to sync documents: %w", err)
+	}
+
+	diagnostics := []Diagnostic{}
+	for _, d := range c.diagnostics.known(uri) {
+		if rangesOverlap(d.Range, r) {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/codeAction", CodeActionParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Range: r,
+		Context: CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code actions: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("code action error: %s", resp.Error.Message)
+	}
+
+	var raw []json.RawMessage
+	if err := resp.UnmarshalResult(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode code actions: %w", err)
+	}
+
+	actions := make([]CodeAction, 0, len(raw))
+	for _, data := range raw {
+		action, err := decodeCodeAction(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode code action: %w", err)
+		}
+		if codeActionKindMatches(action.Kind, only) {
+			actions = append(actions, action)
+		}
+	}
+
+	return actions, nil
+}
+
+// ResolveCodeAction asks the server to fill in the edit of a code action
+// that was returned without one. Actions that already have an edit, or
+// carry no data to resolve, are returned unchanged.
+func (c *Client) ResolveCodeAction(ctx context.Context, action CodeAction) (*CodeAction, error) {
+	if action.Edit != nil || len(action.Data) == 0 {
+		return &action, nil
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "codeAction/resolve", action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve code action: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("code action resolve error: %s", resp.Error.Message)
+	}
+
+	resolved := action
+	if err := resp.UnmarshalResult(&resolved); err != nil {
+		return nil, fmt.Errorf("failed to decode code action: %w", err)
+	}
+
+	return &resolved, nil
+}
+
+// decodeCodeAction decodes an element of a codeAction result, which is
+// either a CodeAction or a bare Command
+func decodeCodeAction(data json.RawMessage) (CodeAction, error) {
+	var probe struct {
+		Command json.RawMessage `json:"command"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return CodeAction{}, err
+	}
+
+	if len(probe.Command) > 0 && probe.Command[0] == '"' {
+		var command Command
+		if err := json.Unmarshal(data, &command); err != nil {
+			return CodeAction{}, err
+		}
+		return CodeAction{Title: command.Title, Command: &command}, nil
+	}
+
+	var action CodeAction
+	if err := json.Unmarshal(data, &action); err != nil {
+		return CodeAction{}, err
+	}
+	return action, nil
+}
+
+// codeActionKindMatches reports whether kind is one of only or a sub-kind of
+// one of them. An empty filter matches every kind.
+func codeActionKindMatches(kind string, only []string) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, prefix := range only {
+		if kind == prefix || strings.HasPrefix(kind, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// rangesOverlap reports whether two ranges share at least one position
+func rangesOverlap(a, b Range) bool {
+	return !positionBefore(a.End, b.Start) && !positionBefore(b.End, a.Start)
+}
+
+func positionBefore(a, b Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+}
@@ -0,0 +1,154 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCodeActionKindMatches(t *testing.T) {
+	tests := []struct {
+		kind string
+		only []string
+		want bool
+	}{
+		{"quickfix", nil, true},
+		{"source.formatAll.terraform", []string{"source"}, true},
+		{"source.formatAll.terraform", []string{"source.formatAll.terraform"}, true},
+		{"sourceX", []string{"source"}, false},
+		{"quickfix", []string{"source", "refactor"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := codeActionKindMatches(tt.kind, tt.only); got != tt.want {
+			t.Errorf("codeActionKindMatches(%q, %v) = %v, want %v", tt.kind, tt.only, got, tt.want)
+		}
+	}
+}
+
+func TestClient_CodeActions(t *testing.T) {
+	server, client := newTestClient(t)
+	client.SetDiagnosticsWait(time.Second, 10*time.Millisecond)
+
+	uri := "file:///workspace/main.tf"
+	content := "locals {\n  a=1\n}\n"
+
+	server.Handle("textDocument/didOpen", func(params json.RawMessage) (interface{}, error) {
+		server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI: uri,
+			Diagnostics: []Diagnostic{
+				{Range: Range{Start: Position{Line: 1, Character: 2}, End: Position{Line: 1, Character: 3}}, Message: "inside"},
+				{Range: Range{Start: Position{Line: 5}, End: Position{Line: 5, Character: 1}}, Message: "outside"},
+			},
+		})
+		return nil, nil
+	})
+
+	var params CodeActionParams
+	server.Handle("textDocument/codeAction", func(p json.RawMessage) (interface{}, error) {
+		json.Unmarshal(p, &params)
+		return json.RawMessage(`[
+			{"title": "Format document", "kind": "source.formatAll.terraform", "data": {"id": 1}},
+			{"title": "Remove attribute", "kind": "quickfix", "isPreferred": true},
+			{"title": "Run init", "command": "terraform-ls.terraform.init", "arguments": ["file:///workspace"]}
+		]`), nil
+	})
+	server.Handle("codeAction/resolve", func(p json.RawMessage) (interface{}, error) {
+		var action map[string]interface{}
+		json.Unmarshal(p, &action)
+		action["edit"] = WorkspaceEdit{Changes: map[string][]TextEdit{
+			uri: {{Range: Range{Start: Position{Line: 1, Character: 3}, End: Position{Line: 1, Character: 4}}, NewText: " = "}},
+		}}
+		return action, nil
+	})
+
+	if _, err := client.ValidateDocument(testContext(t), uri, content); err != nil {
+		t.Fatalf("Failed to validate document: %v", err)
+	}
+
+	r := Range{Start: Position{Line: 0}, End: Position{Line: 2, Character: 1}}
+	actions, err := client.CodeActions(testContext(t), uri, content, r, []string{CodeActionKindSource})
+	if err != nil {
+		t.Fatalf("Failed to get code actions: %v", err)
+	}
+
+	if len(params.Context.Diagnostics) != 1 || params.Context.Diagnostics[0].Message != "inside" {
+		t.Errorf("Expected only the diagnostic inside the range to be sent, got: %+v", params.Context.Diagnostics)
+	}
+	if len(params.Context.Only) != 1 || params.Context.Only[0] != "source" {
+		t.Errorf("Expected kind filter to be sent, got: %v", params.Context.Only)
+	}
+	if len(actions) != 1 || actions[0].Title != "Format document" {
+		t.Fatalf("Expected only the source action, got: %+v", actions)
+	}
+
+	all, err := client.CodeActions(testContext(t), uri, content, r, nil)
+	if err != nil {
+		t.Fatalf("Failed to get code actions: %v", err)
+	}
+	if len(all) != 3 || all[2].Command == nil || all[2].Command.Command != "terraform-ls.terraform.init" {
+		t.Errorf("Expected a bare command to be decoded as an action, got: %+v", all)
+	}
+
+	resolved, err := client.ResolveCodeAction(testContext(t), actions[0])
+	if err != nil {
+		t.Fatalf("Failed to resolve code action: %v", err)
+	}
+	if resolved.Edit == nil {
+		t.Fatal("Expected resolved action to have an edit")
+	}
+
+	changes, err := client.ResolveWorkspaceEdit(*resolved.Edit)
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace edit: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Content != "locals {\n  a = 1\n}\n" {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+}
+
+func TestClient_CodeActionEditsRejectStaleFiles(t *testing.T) {
+	server, client := newTestClient(t)
+
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.tf")
+	content := "locals {\n  a=1\n}\n"
+	if err := os.WriteFile(mainPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	uri := PathToURI(mainPath)
+
+	server.Handle("textDocument/codeAction", func(p json.RawMessage) (interface{}, error) {
+		return []CodeAction{{
+			Title: "Format document",
+			Edit: &WorkspaceEdit{Changes: map[string][]TextEdit{
+				uri: {{Range: Range{Start: Position{Line: 1, Character: 3}, End: Position{Line: 1, Character: 4}}, NewText: " = "}},
+			}},
+		}}, nil
+	})
+
+	actions, err := client.CodeActions(testContext(t), uri, content, Range{End: Position{Line: 3}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to get code actions: %v", err)
+	}
+	changes, err := client.ResolveWorkspaceEdit(*actions[0].Edit)
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace edit: %v", err)
+	}
+
+	// The file is edited on disk before the action is applied
+	edited := "locals {\n  a=1\n  b=2\n}\n"
+	if err := os.WriteFile(mainPath, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := client.ApplyFileChanges(testContext(t), changes); !errors.Is(err, ErrStaleFile) {
+		t.Fatalf("Expected ErrStaleFile, got: %v", err)
+	}
+	if data, _ := os.ReadFile(mainPath); string(data) != edited {
+		t.Errorf("Expected the edit on disk to be kept, got: %q", data)
+	}
+}
@@ -0,0 +1,162 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// showReferencesCommandID is announced to terraform-ls as the client command
+// that shows references. terraform-ls only offers reference count code lenses
+// when the client names such a command; the command itself is never run.
+const showReferencesCommandID = "client.showReferences"
+
+// CodeLenses returns the code lenses of a document
+func (c *Client) CodeLenses(ctx context.Context, uri, content string) ([]CodeLens, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/codeLens", CodeLensParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code lenses: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("code lens error: %s", resp.Error.Message)
+	}
+
+	var lenses []CodeLens
+	if err := resp.UnmarshalResult(&lenses); err != nil {
+		return nil, fmt.Errorf("failed to decode code lenses: %w", err)
+	}
+
+	return lenses, nil
+}
+
+// ResolveCodeLens asks the server to fill in the command of a code lens.
+// Lenses that already have a command are returned unchanged.
+func (c *Client) ResolveCodeLens(ctx context.Context, lens CodeLens) (*CodeLens, error) {
+	if lens.Command != nil {
+		return &lens, nil
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "codeLens/resolve", lens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve code lens: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("code lens resolve error: %s", resp.Error.Message)
+	}
+
+	resolved := lens
+	if err := resp.UnmarshalResult(&resolved); err != nil {
+		return nil, fmt.Errorf("failed to decode code lens: %w", err)
+	}
+
+	return &resolved, nil
+}
+
+// ReferenceCounts returns how often each variable, output and local value
+// declared in a document is referenced. Counts come from the reference count
+// code lenses of terraform-ls; declarations without a lens are counted with
+// textDocument/references instead.
+func (c *Client) ReferenceCounts(ctx context.Context, uri, content string) ([]ReferenceCount, error) {
+	symbols, err := c.DocumentSymbols(ctx, uri, content)
+	if err != nil {
+		return nil, err
+	}
+
+	lenses, err := c.CodeLenses(ctx, uri, content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lenses are placed on the first line of the declaration they count
+	lensCounts := make(map[int]int)
+	for _, lens := range lenses {
+		resolved, err := c.ResolveCodeLens(ctx, lens)
+		if err != nil {
+			return nil, err
+		}
+		if resolved.Command == nil {
+			continue
+		}
+		if count, ok := parseReferenceCount(resolved.Command.Title); ok {
+			lensCounts[resolved.Range.Start.Line] = count
+		}
+	}
+
+	var counts []ReferenceCount
+	for _, declaration := range referenceTargets(symbols) {
+		declaration.URI = uri
+
+		if count, ok := lensCounts[declaration.Range.Start.Line]; ok {
+			declaration.References = count
+		} else {
+			pos := declaration.Range.Start
+			refs, err := c.References(ctx, uri, content, pos.Line, pos.Character, false)
+			if err != nil {
+				return nil, err
+			}
+			declaration.References = len(refs)
+		}
+
+		counts = append(counts, declaration)
+	}
+
+	return counts, nil
+}
+
+// referenceTargets picks the variable and output blocks and the local values
+// out of a document outline
+func referenceTargets(symbols []DocumentSymbol) []ReferenceCount {
+	var targets []ReferenceCount
+
+	for _, symbol := range symbols {
+		blockType, label := splitBlockName(symbol.Name)
+		switch blockType {
+		case "variable", "output":
+			if label != "" {
+				targets = append(targets, ReferenceCount{Kind: blockType, Name: label, Range: symbol.SelectionRange})
+			}
+		case "locals":
+			for _, local := range symbol.Children {
+				targets = append(targets, ReferenceCount{Kind: "local", Name: local.Name, Range: local.SelectionRange})
+			}
+		}
+	}
+
+	return targets
+}
+
+// splitBlockName splits a block symbol name such as variable "region" into
+// its type and first label
+func splitBlockName(name string) (blockType, label string) {
+	blockType, rest, _ := strings.Cut(name, " ")
+	label, _ = strconv.Unquote(strings.TrimSpace(rest))
+	return blockType, label
+}
+
+// parseReferenceCount reads the count from a lens title such as
+// "1 reference", "3 references" or "No references"
+func parseReferenceCount(title string) (int, bool) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(title), " ")
+	if !strings.HasPrefix(strings.ToLower(rest), "reference") {
+		return 0, false
+	}
+	if strings.EqualFold(first, "no") {
+		return 0, true
+	}
+	count, err := strconv.Atoi(first)
+	if err != nil {
+		return 0, false
+	}
+	return count, true
+}
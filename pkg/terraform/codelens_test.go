@@ -0,0 +1,83 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseReferenceCount(t *testing.T) {
+	tests := []struct {
+		title string
+		want  int
+		ok    bool
+	}{
+		{"1 reference", 1, true},
+		{"12 references", 12, true},
+		{"No references", 0, true},
+		{"Run terraform init", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseReferenceCount(tt.title)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseReferenceCount(%q) = %d, %v, want %d, %v", tt.title, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestClient_ReferenceCounts(t *testing.T) {
+	server, client := newTestClient(t)
+
+	content := "variable \"region\" {}\n\nlocals {\n  name = \"x\"\n}\n\noutput \"id\" {\n  value = 1\n}\n"
+	server.Handle("textDocument/documentSymbol", func(params json.RawMessage) (interface{}, error) {
+		return []DocumentSymbol{
+			{Name: "variable \"region\"", Kind: 5, Range: Range{End: Position{Character: 20}}, SelectionRange: Range{End: Position{Character: 20}}},
+			{
+				Name:  "locals",
+				Kind:  5,
+				Range: Range{Start: Position{Line: 2}, End: Position{Line: 4, Character: 1}},
+				Children: []DocumentSymbol{
+					{Name: "name", Kind: 15, Range: Range{Start: Position{Line: 3, Character: 2}}, SelectionRange: Range{Start: Position{Line: 3, Character: 2}}},
+				},
+			},
+			{Name: "output \"id\"", Kind: 5, Range: Range{Start: Position{Line: 6}}, SelectionRange: Range{Start: Position{Line: 6}}},
+		}, nil
+	})
+	server.Handle("textDocument/codeLens", func(params json.RawMessage) (interface{}, error) {
+		return []CodeLens{
+			{Range: Range{Start: Position{Line: 0}}, Command: &Command{Title: "2 references", Command: showReferencesCommandID}},
+			{Range: Range{Start: Position{Line: 3, Character: 2}}, Data: json.RawMessage(`{"id": 1}`)},
+		}, nil
+	})
+	server.Handle("codeLens/resolve", func(params json.RawMessage) (interface{}, error) {
+		var lens CodeLens
+		json.Unmarshal(params, &lens)
+		lens.Command = &Command{Title: "No references", Command: showReferencesCommandID}
+		return lens, nil
+	})
+	server.Handle("textDocument/references", func(params json.RawMessage) (interface{}, error) {
+		return []Location{{URI: "file:///parent/main.tf"}}, nil
+	})
+
+	counts, err := client.ReferenceCounts(testContext(t), "file:///workspace/main.tf", content)
+	if err != nil {
+		t.Fatalf("Failed to count references: %v", err)
+	}
+
+	want := []ReferenceCount{
+		{Kind: "variable", Name: "region", References: 2},
+		{Kind: "local", Name: "name", References: 0},
+		{Kind: "output", Name: "id", References: 1},
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("Expected %d counts, got: %+v", len(want), counts)
+	}
+	for i, w := range want {
+		if counts[i].Kind != w.Kind || counts[i].Name != w.Name || counts[i].References != w.References {
+			t.Errorf("Count %d: expected %+v, got: %+v", i, w, counts[i])
+		}
+	}
+	if got := len(server.Received("textDocument/references")); got != 1 {
+		t.Errorf("Expected references to be used only for the output without a lens, got: %d", got)
+	}
+}
@@ -0,0 +1,211 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// terraform-ls commands, run with workspace/executeCommand
+const (
+	CommandModuleCalls       = "terraform-ls.module.calls"
+	CommandModuleProviders   = "terraform-ls.module.providers"
+	CommandModuleCallers     = "terraform-ls.module.callers"
+	CommandModuleTerraform   = "terraform-ls.module.terraform"
+	CommandTerraformInit     = "terraform-ls.terraform.init"
+	CommandTerraformValidate = "terraform-ls.terraform.validate"
+)
+
+// ExecuteCommand runs a command on the server, such as the command of a code
+// action, and returns its raw result
+func (c *Client) ExecuteCommand(ctx context.Context, command string, arguments ...interface{}) (json.RawMessage, error) {
+	resp, err := c.lspClient.SendRequest(ctx, "workspace/executeCommand", ExecuteCommandParams{
+		Command:   c.commandName(command),
+		Arguments: arguments,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", command, err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("execute command error: %s", resp.Error.Message)
+	}
+
+	var result json.RawMessage
+	if err := resp.UnmarshalResult(&result); err != nil {
+		return nil, fmt.Errorf("failed to read %s result: %w", command, err)
+	}
+
+	return result, nil
+}
+
+// ModuleCalls returns the module calls of the module in a directory, with
+// the calls of each called module nested below it
+func (c *Client) ModuleCalls(ctx context.Context, moduleURI string) (*ModuleCallsResult, error) {
+	var result ModuleCallsResult
+	if err := c.executeModuleCommand(ctx, CommandModuleCalls, moduleURI, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ModuleProviders returns the provider requirements of a module and the
+// provider versions installed for it
+func (c *Client) ModuleProviders(ctx context.Context, moduleURI string) (*ModuleProvidersResult, error) {
+	var result ModuleProvidersResult
+	if err := c.executeModuleCommand(ctx, CommandModuleProviders, moduleURI, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ModuleCallers returns the modules in the workspace that call a module
+func (c *Client) ModuleCallers(ctx context.Context, moduleURI string) (*ModuleCallersResult, error) {
+	var result ModuleCallersResult
+	if err := c.executeModuleCommand(ctx, CommandModuleCallers, moduleURI, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ModuleTerraform returns the Terraform version a module requires and the
+// version terraform-ls found installed
+func (c *Client) ModuleTerraform(ctx context.Context, moduleURI string) (*ModuleTerraformResult, error) {
+	var result ModuleTerraformResult
+	if err := c.executeModuleCommand(ctx, CommandModuleTerraform, moduleURI, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// NewModuleCallTree combines the results of the module calls and module
+// callers commands. Missing lists are empty rather than nil.
+func NewModuleCallTree(calls *ModuleCallsResult, callers *ModuleCallersResult) *ModuleCallTree {
+	tree := &ModuleCallTree{
+		ModuleCalls: moduleCallInfos(calls.ModuleCalls),
+		Callers:     []ModuleCaller{},
+	}
+	if tree.ModuleCalls == nil {
+		tree.ModuleCalls = []ModuleCallInfo{}
+	}
+	if callers.Callers != nil {
+		tree.Callers = callers.Callers
+	}
+	return tree
+}
+
+func moduleCallInfos(calls []ModuleCall) []ModuleCallInfo {
+	if calls == nil {
+		return nil
+	}
+
+	infos := make([]ModuleCallInfo, len(calls))
+	for i, call := range calls {
+		infos[i] = ModuleCallInfo{
+			Name:             call.Name,
+			SourceAddr:       call.SourceAddr,
+			Version:          call.Version,
+			SourceType:       call.SourceType,
+			DocsLink:         call.DocsLink,
+			DependentModules: moduleCallInfos(call.DependentModules),
+		}
+	}
+	return infos
+}
+
+// NewModuleProviderInfo combines the results of the module providers and
+// module Terraform commands. Missing maps are empty rather than nil.
+func NewModuleProviderInfo(providers *ModuleProvidersResult, version *ModuleTerraformResult) *ModuleProviderInfo {
+	info := &ModuleProviderInfo{
+		ProviderRequirements: make(map[string]ProviderRequirementInfo, len(providers.ProviderRequirements)),
+		InstalledProviders:   map[string]string{},
+		Terraform: TerraformVersionInfo{
+			RequiredVersion:   version.RequiredVersion,
+			DiscoveredVersion: version.DiscoveredVersion,
+		},
+	}
+	for address, requirement := range providers.ProviderRequirements {
+		info.ProviderRequirements[address] = ProviderRequirementInfo{
+			DisplayName:       requirement.DisplayName,
+			VersionConstraint: requirement.VersionConstraint,
+			DocsLink:          requirement.DocsLink,
+		}
+	}
+	if providers.InstalledProviders != nil {
+		info.InstalledProviders = providers.InstalledProviders
+	}
+	return info
+}
+
+// TerraformInit runs terraform init in a module directory
+func (c *Client) TerraformInit(ctx context.Context, moduleURI string) error {
+	return c.executeModuleCommand(ctx, CommandTerraformInit, moduleURI, nil)
+}
+
+// TerraformValidate runs terraform validate in a module directory. The
+// server publishes the results as diagnostics, which are collected until no
+// more arrive for the settle period. Only the files of the module itself
+// are reported, not those of nested modules, and only with diagnostics
+// published by this run.
+func (c *Client) TerraformValidate(ctx context.Context, moduleURI string) (*ModuleValidationResult, error) {
+	since := c.diagnostics.generationIn(moduleURI)
+
+	if err := c.executeModuleCommand(ctx, CommandTerraformValidate, moduleURI, nil); err != nil {
+		return nil, err
+	}
+
+	timeout, settle := c.diagnosticsWait()
+	timedOut, err := c.diagnostics.waitIn(ctx, moduleURI, since, timeout, settle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ModuleValidationResult{
+		URI:         moduleURI,
+		Diagnostics: c.diagnostics.publishedIn(moduleURI, since),
+		TimedOut:    timedOut,
+	}, nil
+}
+
+// executeModuleCommand runs a terraform-ls command that takes a module URI
+// and decodes its result into result, unless result is nil
+func (c *Client) executeModuleCommand(ctx context.Context, command, moduleURI string, result interface{}) error {
+	raw, err := c.ExecuteCommand(ctx, command, "uri="+moduleURI)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", command, err)
+	}
+	return nil
+}
+
+// commandName returns the name under which the server registered a
+// command. terraform-ls can be configured with a command prefix, in which
+// case the advertised name ends with the unprefixed one.
+func (c *Client) commandName(command string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capabilities.ExecuteCommandProvider == nil {
+		return command
+	}
+
+	commands := c.capabilities.ExecuteCommandProvider.Commands
+	for _, name := range commands {
+		if name == command {
+			return name
+		}
+	}
+	for _, name := range commands {
+		if strings.HasSuffix(name, "."+command) {
+			return name
+		}
+	}
+	return command
+}
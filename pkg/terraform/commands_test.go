@@ -0,0 +1,154 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestClient_ModuleCommands(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`{"capabilities": {"executeCommandProvider": {"commands": [
+			"tf.terraform-ls.module.calls", "tf.terraform-ls.module.providers"
+		]}}}`), nil
+	})
+
+	var commands []ExecuteCommandParams
+	server.Handle("workspace/executeCommand", func(params json.RawMessage) (interface{}, error) {
+		var p ExecuteCommandParams
+		json.Unmarshal(params, &p)
+		commands = append(commands, p)
+
+		switch p.Command {
+		case "tf.terraform-ls.module.calls":
+			return json.RawMessage(`{"v": 0, "module_calls": [{
+				"name": "vpc", "source_addr": "terraform-aws-modules/vpc/aws", "version": "5.0.0",
+				"source_type": "tfregistry", "docs_link": "https://registry.terraform.io/modules/terraform-aws-modules/vpc/aws/5.0.0",
+				"dependent_modules": [{"name": "subnets", "source_addr": "./modules/subnets", "source_type": "local"}]
+			}]}`), nil
+		case "tf.terraform-ls.module.providers":
+			return json.RawMessage(`{"v": 0,
+				"provider_requirements": {"registry.terraform.io/hashicorp/aws": {"display_name": "hashicorp/aws", "version_constraint": "~> 5.0"}},
+				"installed_providers": {"registry.terraform.io/hashicorp/aws": "5.31.0"}
+			}`), nil
+		case CommandModuleTerraform:
+			return ModuleTerraformResult{RequiredVersion: ">= 1.5", DiscoveredVersion: "1.6.6"}, nil
+		}
+		return nil, nil
+	})
+
+	ctx := testContext(t)
+	if err := client.Initialize(ctx, "/workspace"); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	calls, err := client.ModuleCalls(ctx, "file:///workspace")
+	if err != nil {
+		t.Fatalf("Failed to get module calls: %v", err)
+	}
+	if len(calls.ModuleCalls) != 1 || len(calls.ModuleCalls[0].DependentModules) != 1 {
+		t.Fatalf("Expected a nested module call, got: %+v", calls)
+	}
+	if commands[0].Command != "tf.terraform-ls.module.calls" {
+		t.Errorf("Expected the prefixed command name, got: %s", commands[0].Command)
+	}
+	if len(commands[0].Arguments) != 1 || commands[0].Arguments[0] != "uri=file:///workspace" {
+		t.Errorf("Expected uri argument, got: %v", commands[0].Arguments)
+	}
+
+	providers, err := client.ModuleProviders(ctx, "file:///workspace")
+	if err != nil {
+		t.Fatalf("Failed to get module providers: %v", err)
+	}
+	if providers.InstalledProviders["registry.terraform.io/hashicorp/aws"] != "5.31.0" {
+		t.Errorf("Expected installed version, got: %+v", providers.InstalledProviders)
+	}
+	if providers.ProviderRequirements["registry.terraform.io/hashicorp/aws"].VersionConstraint != "~> 5.0" {
+		t.Errorf("Expected version constraint, got: %+v", providers.ProviderRequirements)
+	}
+
+	version, err := client.ModuleTerraform(ctx, "file:///workspace")
+	if err != nil {
+		t.Fatalf("Failed to get Terraform version: %v", err)
+	}
+	if version.DiscoveredVersion != "1.6.6" {
+		t.Errorf("Expected discovered version, got: %+v", version)
+	}
+	if commands[2].Command != CommandModuleTerraform {
+		t.Errorf("Expected unadvertised command to be sent unchanged, got: %s", commands[2].Command)
+	}
+}
+
+func TestClient_TerraformValidate(t *testing.T) {
+	server, client := newTestClient(t)
+	client.SetDiagnosticsWait(2*time.Second, 20*time.Millisecond)
+
+	// Diagnostics published before the run are not part of its result
+	server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         "file:///workspace/variables.tf",
+		Diagnostics: []Diagnostic{{Message: "stale"}},
+	})
+	for client.diagnostics.get("file:///workspace/variables.tf") == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	server.Handle("workspace/executeCommand", func(params json.RawMessage) (interface{}, error) {
+		server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         "file:///workspace/main.tf",
+			Diagnostics: []Diagnostic{{Severity: SeverityError, Message: "Unsupported argument"}},
+		})
+		server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         "file:///workspace/outputs.tf",
+			Diagnostics: []Diagnostic{},
+		})
+		server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         "file:///workspace-other/main.tf",
+			Diagnostics: []Diagnostic{{Message: "other module"}},
+		})
+		server.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         "file:///workspace/modules/vpc/main.tf",
+			Diagnostics: []Diagnostic{{Message: "nested module"}},
+		})
+		return nil, nil
+	})
+
+	result, err := client.TerraformValidate(testContext(t), "file:///workspace")
+	if err != nil {
+		t.Fatalf("Failed to run terraform validate: %v", err)
+	}
+	if result.TimedOut {
+		t.Error("Expected diagnostics before the timeout")
+	}
+	if len(result.Diagnostics) != 1 || len(result.Diagnostics["file:///workspace/main.tf"]) != 1 {
+		t.Errorf("Expected diagnostics for main.tf only, got: %+v", result.Diagnostics)
+	}
+}
+
+func TestModuleInfoJSON(t *testing.T) {
+	tree := NewModuleCallTree(&ModuleCallsResult{
+		ModuleCalls: []ModuleCall{{
+			Name:             "vpc",
+			SourceAddr:       "./modules/vpc",
+			DependentModules: []ModuleCall{{Name: "subnets", SourceAddr: "./subnets"}},
+		}},
+	}, &ModuleCallersResult{})
+
+	data, _ := json.Marshal(tree)
+	want := `{"moduleCalls":[{"name":"vpc","sourceAddr":"./modules/vpc","dependentModules":[{"name":"subnets","sourceAddr":"./subnets"}]}],"callers":[]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got: %s", want, data)
+	}
+
+	info := NewModuleProviderInfo(&ModuleProvidersResult{
+		ProviderRequirements: map[string]ProviderRequirement{
+			"registry.terraform.io/hashicorp/aws": {DisplayName: "hashicorp/aws", VersionConstraint: "~> 5.0"},
+		},
+	}, &ModuleTerraformResult{RequiredVersion: ">= 1.5"})
+
+	data, _ = json.Marshal(info)
+	want = `{"providerRequirements":{"registry.terraform.io/hashicorp/aws":{"displayName":"hashicorp/aws","versionConstraint":"~\u003e 5.0"}},"installedProviders":{},"terraform":{"requiredVersion":"\u003e= 1.5"}}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got: %s", want, data)
+	}
+}
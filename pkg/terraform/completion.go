@@ -0,0 +1,258 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// completionItemKinds maps LSP CompletionItemKind values to names
+var completionItemKinds = []string{
+	1: "text", 2: "method", 3: "function", 4: "constructor", 5: "field",
+	6: "variable", 7: "class", 8: "interface", 9: "module", 10: "property",
+	11: "unit", 12: "value", 13: "enum", 14: "keyword", 15: "snippet",
+	16: "color", 17: "file", 18: "reference", 19: "folder", 20: "enum member",
+	21: "constant", 22: "struct", 23: "event", 24: "operator", 25: "type parameter",
+}
+
+// CompletionItemKindName returns a readable name for a CompletionItemKind
+func CompletionItemKindName(kind int) string {
+	if kind > 0 && kind < len(completionItemKinds) {
+		return completionItemKinds[kind]
+	}
+	return ""
+}
+
+// UnmarshalJSON decodes a completion item whose documentation may be either
+// a plain string or MarkupContent, and whose text edit may be either a
+// TextEdit or an InsertReplaceEdit
+func (item *CompletionItem) UnmarshalJSON(data []byte) error {
+	type completionItem CompletionItem
+	var raw struct {
+		completionItem
+		Documentation json.RawMessage `json:"documentation,omitempty"`
+		TextEdit      *struct {
+			NewText string `json:"newText"`
+			Range   *Range `json:"range"`
+			Insert  *Range `json:"insert"`
+			Replace *Range `json:"replace"`
+		} `json:"textEdit,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*item = CompletionItem(raw.completionItem)
+	item.raw = append(json.RawMessage(nil), data...)
+	item.Documentation, item.DocumentationFormat = "", ""
+	item.TextEdit, item.ReplaceRange = nil, nil
+
+	if edit := raw.TextEdit; edit != nil {
+		switch {
+		case edit.Range != nil:
+			item.TextEdit = &TextEdit{Range: *edit.Range, NewText: edit.NewText}
+		case edit.Insert != nil:
+			item.TextEdit = &TextEdit{Range: *edit.Insert, NewText: edit.NewText}
+			item.ReplaceRange = edit.Replace
+		default:
+			return fmt.Errorf("invalid text edit: no range")
+		}
+	}
+
+	doc := bytes.TrimSpace(raw.Documentation)
+	switch {
+	case len(doc) == 0 || bytes.Equal(doc, []byte("null")):
+	case doc[0] == '"':
+		if err := json.Unmarshal(doc, &item.Documentation); err != nil {
+			return fmt.Errorf("invalid documentation: %w", err)
+		}
+		item.DocumentationFormat = "plaintext"
+	default:
+		var markup MarkupContent
+		if err := json.Unmarshal(doc, &markup); err != nil {
+			return fmt.Errorf("invalid documentation: %w", err)
+		}
+		item.Documentation, item.DocumentationFormat = markup.Value, markup.Kind
+	}
+
+	return nil
+}
+
+// InsertionText returns the text the item inserts into the document. Snippet
+// tab stops and placeholders are replaced with their default text unless
+// keepSnippet is set.
+func (item CompletionItem) InsertionText(keepSnippet bool) string {
+	text := item.Label
+	switch {
+	case item.TextEdit != nil:
+		text = item.TextEdit.NewText
+	case item.InsertText != "":
+		text = item.InsertText
+	}
+
+	if item.InsertTextFormat == InsertTextFormatSnippet && !keepSnippet {
+		return ExpandSnippet(text)
+	}
+	return text
+}
+
+// decodeCompletionResult decodes a textDocument/completion result, which is
+// either a CompletionList, a bare CompletionItem array or null
+func decodeCompletionResult(data json.RawMessage) (*CompletionList, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return &CompletionList{}, nil
+	}
+
+	if data[0] == '[' {
+		var items []CompletionItem
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		return &CompletionList{Items: items}, nil
+	}
+
+	var list CompletionList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// sortCompletionItems orders items the way editors present them: by
+// sortText, falling back to the label, with ties broken by label
+func sortCompletionItems(items []CompletionItem) {
+	key := func(item CompletionItem) string {
+		if item.SortText != "" {
+			return item.SortText
+		}
+		return item.Label
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		ki, kj := key(items[i]), key(items[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return items[i].Label < items[j].Label
+	})
+}
+
+// ResolveCompletionItem asks the server to fill in lazily computed
+// properties, such as documentation, of a completion item. Items decoded
+// from a completion result are sent back exactly as the server sent them.
+func (c *Client) ResolveCompletionItem(ctx context.Context, item CompletionItem) (*CompletionItem, error) {
+	var params interface{} = item
+	if len(item.raw) > 0 {
+		params = item.raw
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "completionItem/resolve", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve completion item: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("completion resolve error: %s", resp.Error.Message)
+	}
+
+	resolved := item
+	if err := resp.UnmarshalResult(&resolved); err != nil {
+		return nil, fmt.Errorf("failed to decode completion item: %w", err)
+	}
+
+	return &resolved, nil
+}
+
+// ExpandSnippet converts LSP snippet syntax to plain text. Tab stops are
+// removed, placeholders and variables are replaced with their default text,
+// choices with their first option, and escapes are resolved.
+func ExpandSnippet(snippet string) string {
+	var b strings.Builder
+	expandSnippet(&b, snippet, 0, false)
+	return b.String()
+}
+
+// expandSnippet writes the expansion of snippet starting at i to b and
+// returns the index after the consumed text. When nested is set it stops at
+// the closing brace of the enclosing placeholder.
+func expandSnippet(b *strings.Builder, snippet string, i int, nested bool) int {
+	for i < len(snippet) {
+		ch := snippet[i]
+		switch {
+		case ch == '\\' && i+1 < len(snippet) && strings.ContainsRune(`$}\`, rune(snippet[i+1])):
+			b.WriteByte(snippet[i+1])
+			i += 2
+		case ch == '}' && nested:
+			return i + 1
+		case ch == '$':
+			i = expandSnippetElement(b, snippet, i+1)
+		default:
+			b.WriteByte(ch)
+			i++
+		}
+	}
+	return i
+}
+
+// expandSnippetElement expands the tab stop, placeholder, choice or variable
+// whose name starts at i, just after the '$'
+func expandSnippetElement(b *strings.Builder, snippet string, i int) int {
+	if i >= len(snippet) {
+		b.WriteByte('$')
+		return i
+	}
+
+	// $1 or $name
+	if snippet[i] != '{' {
+		j := i
+		for j < len(snippet) && isSnippetNameChar(snippet[j]) {
+			j++
+		}
+		if j == i {
+			b.WriteByte('$')
+		}
+		return j
+	}
+
+	// ${1}, ${1:default}, ${1|a,b|}, ${name} or ${name:default}
+	j := i + 1
+	for j < len(snippet) && isSnippetNameChar(snippet[j]) {
+		j++
+	}
+	if j == i+1 || j >= len(snippet) {
+		b.WriteString("${")
+		return i + 1
+	}
+
+	switch snippet[j] {
+	case '}':
+		return j + 1
+	case ':':
+		return expandSnippet(b, snippet, j+1, true)
+	case '|':
+		end := strings.Index(snippet[j+1:], "|}")
+		if end < 0 {
+			b.WriteString(snippet[i-1:])
+			return len(snippet)
+		}
+		choices := snippet[j+1 : j+1+end]
+		if first, _, found := strings.Cut(choices, ","); found {
+			choices = first
+		}
+		b.WriteString(choices)
+		return j + 1 + end + 2
+	default:
+		// Transforms such as ${1/regex/format/} are not supported; keep the
+		// text as written
+		b.WriteString(snippet[i-1 : j])
+		return j
+	}
+}
+
+func isSnippetNameChar(ch byte) bool {
+	return ch == '_' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
@@ -0,0 +1,117 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeCompletionResult(t *testing.T) {
+	list, err := decodeCompletionResult(json.RawMessage(`{
+		"isIncomplete": true,
+		"items": [
+			{"label": "ami", "documentation": {"kind": "markdown", "value": "**AMI** to use"}},
+			{"label": "tags", "documentation": "Resource tags"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to decode completion list: %v", err)
+	}
+	if !list.IsIncomplete || len(list.Items) != 2 {
+		t.Fatalf("Unexpected list: %+v", list)
+	}
+	if list.Items[0].Documentation != "**AMI** to use" || list.Items[0].DocumentationFormat != "markdown" {
+		t.Errorf("Expected markdown documentation, got: %q (%s)", list.Items[0].Documentation, list.Items[0].DocumentationFormat)
+	}
+	if list.Items[1].Documentation != "Resource tags" || list.Items[1].DocumentationFormat != "plaintext" {
+		t.Errorf("Expected plaintext documentation, got: %q (%s)", list.Items[1].Documentation, list.Items[1].DocumentationFormat)
+	}
+
+	list, err = decodeCompletionResult(json.RawMessage(`[{"label": "resource"}]`))
+	if err != nil || len(list.Items) != 1 || list.IsIncomplete {
+		t.Errorf("Expected one item from array result, got: %+v (%v)", list, err)
+	}
+
+	list, err = decodeCompletionResult(json.RawMessage(`null`))
+	if err != nil || len(list.Items) != 0 {
+		t.Errorf("Expected empty list for null result, got: %+v (%v)", list, err)
+	}
+}
+
+func TestSortCompletionItems(t *testing.T) {
+	items := []CompletionItem{
+		{Label: "zone"},
+		{Label: "ami", SortText: "2"},
+		{Label: "instance_type", SortText: "1"},
+		{Label: "arn"},
+	}
+
+	sortCompletionItems(items)
+
+	want := []string{"instance_type", "ami", "arn", "zone"}
+	for i, label := range want {
+		if items[i].Label != label {
+			t.Errorf("Expected %s at position %d, got: %s", label, i, items[i].Label)
+		}
+	}
+}
+
+func TestExpandSnippet(t *testing.T) {
+	cases := map[string]string{
+		`resource "${1:type}" "${2:name}" {\n\t$0\n}`: `resource "type" "name" {\n\t\n}`,
+		`cidrsubnet(${1:prefix}, ${2:newbits}, $3)`:   `cidrsubnet(prefix, newbits, )`,
+		`${1|true,false|}`:                            `true`,
+		`${1:outer ${2:inner}}`:                       `outer inner`,
+		`cost \$5 \} ${TM_FILENAME:main.tf}`:          `cost $5 } main.tf`,
+		`${}`:                                         `${}`,
+		`price $`:                                     `price $`,
+	}
+
+	for snippet, want := range cases {
+		if got := ExpandSnippet(snippet); got != want {
+			t.Errorf("ExpandSnippet(%q): expected %q, got: %q", snippet, want, got)
+		}
+	}
+}
+
+func TestCompletionItem_InsertionText(t *testing.T) {
+	item := CompletionItem{
+		Label:            "tags",
+		InsertText:       "tags = {\n  ${1}\n}",
+		InsertTextFormat: InsertTextFormatSnippet,
+	}
+
+	if got := item.InsertionText(true); got != item.InsertText {
+		t.Errorf("Expected snippet to be kept, got: %q", got)
+	}
+	if got := item.InsertionText(false); got != "tags = {\n  \n}" {
+		t.Errorf("Expected expanded snippet, got: %q", got)
+	}
+
+	item.TextEdit = &TextEdit{NewText: "tags = {}"}
+	if got := item.InsertionText(false); got != "tags = {}" {
+		t.Errorf("Expected text edit to take precedence, got: %q", got)
+	}
+}
+
+func TestCompletionItem_UnmarshalInsertReplaceEdit(t *testing.T) {
+	var items []CompletionItem
+	err := json.Unmarshal([]byte(`[
+		{"label": "ami", "textEdit": {"newText": "ami", "range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 4}}}},
+		{"label": "tags", "textEdit": {"newText": "tags", "insert": {"start": {"line": 2, "character": 2}, "end": {"line": 2, "character": 3}}, "replace": {"start": {"line": 2, "character": 2}, "end": {"line": 2, "character": 6}}}}
+	]`), &items)
+	if err != nil {
+		t.Fatalf("Failed to decode items: %v", err)
+	}
+
+	if edit := items[0].TextEdit; edit == nil || edit.Range.End.Character != 4 || items[0].ReplaceRange != nil {
+		t.Errorf("Unexpected text edit: %+v", items[0])
+	}
+
+	edit := items[1].TextEdit
+	if edit == nil || edit.NewText != "tags" || edit.Range.Start.Line != 2 || edit.Range.End.Character != 3 {
+		t.Errorf("Expected the insert range, got: %+v", edit)
+	}
+	if replace := items[1].ReplaceRange; replace == nil || replace.End.Character != 6 {
+		t.Errorf("Expected the replace range, got: %+v", replace)
+	}
+}
@@ -0,0 +1,219 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default bounds for waiting on pushed diagnostics. terraform-ls validates
+// asynchronously and may publish several times for one change (parsing
+// first, then schema validation), so after the first publish we wait for a
+// short quiet period before reporting.
+const (
+	defaultDiagnosticsTimeout = 5 * time.Second
+	defaultDiagnosticsSettle  = 300 * time.Millisecond
+)
+
+// publishedDiagnostics is the latest textDocument/publishDiagnostics payload
+// for a document
+type publishedDiagnostics struct {
+	generation  uint64
+	diagnostics []Diagnostic
+}
+
+// diagnosticsStore keeps the diagnostics pushed by the server and the last
+// pulled report for each document, keyed by document URI
+type diagnosticsStore struct {
+	mu        sync.Mutex
+	seq       uint64
+	published map[string]*publishedDiagnostics
+	pulled    map[string]DocumentDiagnosticReport
+	changed   chan struct{}
+}
+
+func newDiagnosticsStore() *diagnosticsStore {
+	return &diagnosticsStore{
+		published: make(map[string]*publishedDiagnostics),
+		pulled:    make(map[string]DocumentDiagnosticReport),
+		changed:   make(chan struct{}),
+	}
+}
+
+// handlePublish is registered as the textDocument/publishDiagnostics handler
+func (s *diagnosticsStore) handlePublish(method string, params json.RawMessage) {
+	var p PublishDiagnosticsParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	s.published[p.URI] = &publishedDiagnostics{
+		generation:  s.seq,
+		diagnostics: p.Diagnostics,
+	}
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// get returns a copy of the diagnostics last published for uri
+func (s *diagnosticsStore) get(uri string) []Diagnostic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	published := s.published[uri]
+	if published == nil || published.diagnostics == nil {
+		return nil
+	}
+	return append([]Diagnostic(nil), published.diagnostics...)
+}
+
+// known returns the latest diagnostics for uri, preferring a pulled report
+// over published diagnostics
+func (s *diagnosticsStore) known(uri string) []Diagnostic {
+	s.mu.Lock()
+	report, pulled := s.pulled[uri]
+	s.mu.Unlock()
+
+	if pulled {
+		return append([]Diagnostic(nil), report.Items...)
+	}
+	return s.get(uri)
+}
+
+// generation returns a marker that changes every time diagnostics are
+// published for uri
+func (s *diagnosticsStore) generation(uri string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generationLocked(uri)
+}
+
+func (s *diagnosticsStore) generationLocked(uri string) uint64 {
+	if published := s.published[uri]; published != nil {
+		return published.generation
+	}
+	return 0
+}
+
+// generationIn returns a marker that changes every time diagnostics are
+// published for a file directly in the directory dirURI
+func (s *diagnosticsStore) generationIn(dirURI string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generationInLocked(dirURI)
+}
+
+func (s *diagnosticsStore) generationInLocked(dirURI string) uint64 {
+	var latest uint64
+	for uri, published := range s.published {
+		if inDirectory(uri, dirURI) && published.generation > latest {
+			latest = published.generation
+		}
+	}
+	return latest
+}
+
+// publishedIn returns the diagnostics published after since for the files
+// directly in the directory dirURI, omitting files without diagnostics
+func (s *diagnosticsStore) publishedIn(dirURI string, since uint64) map[string][]Diagnostic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string][]Diagnostic)
+	for uri, published := range s.published {
+		if inDirectory(uri, dirURI) && published.generation > since && len(published.diagnostics) > 0 {
+			result[uri] = append([]Diagnostic(nil), published.diagnostics...)
+		}
+	}
+	return result
+}
+
+// inDirectory reports whether uri names a file directly in the directory
+// dirURI, not in a subdirectory
+func inDirectory(uri, dirURI string) bool {
+	name, ok := strings.CutPrefix(uri, strings.TrimSuffix(dirURI, "/")+"/")
+	return ok && name != "" && !strings.Contains(name, "/")
+}
+
+// wait blocks until diagnostics newer than since have been published for uri
+// and no further publish has arrived for the settle period. It reports
+// timedOut if nothing was published before the timeout.
+func (s *diagnosticsStore) wait(ctx context.Context, uri string, since uint64, timeout, settle time.Duration) (timedOut bool, err error) {
+	return s.waitGeneration(ctx, func() uint64 { return s.generationLocked(uri) }, since, timeout, settle)
+}
+
+// waitIn is like wait, for publishes to any file directly in the directory
+// dirURI
+func (s *diagnosticsStore) waitIn(ctx context.Context, dirURI string, since uint64, timeout, settle time.Duration) (timedOut bool, err error) {
+	return s.waitGeneration(ctx, func() uint64 { return s.generationInLocked(dirURI) }, since, timeout, settle)
+}
+
+// waitGeneration waits for generationLocked, which is called with the lock
+// held, to move past since and then stay unchanged for the settle period
+func (s *diagnosticsStore) waitGeneration(ctx context.Context, generationLocked func() uint64, since uint64, timeout, settle time.Duration) (timedOut bool, err error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	var quiet <-chan time.Time
+	last := since
+
+	for {
+		s.mu.Lock()
+		current := generationLocked()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if current != last {
+			last = current
+			quiet = time.After(settle)
+		}
+
+		select {
+		case <-changed:
+		case <-quiet:
+			return false, nil
+		case <-deadline.C:
+			return last == since, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// previousResultID returns the result ID of the last full report pulled for uri
+func (s *diagnosticsStore) previousResultID(uri string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pulled[uri].ResultID
+}
+
+// resolvePulled returns the diagnostics described by a pulled report. Full
+// reports are remembered so that a later "unchanged" report for the same
+// document can be answered from them.
+func (s *diagnosticsStore) resolvePulled(uri string, report DocumentDiagnosticReport) []Diagnostic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch report.Kind {
+	case DiagnosticReportUnchanged:
+		previous, ok := s.pulled[uri]
+		if !ok || previous.ResultID != report.ResultID {
+			return nil
+		}
+		return append([]Diagnostic(nil), previous.Items...)
+	default:
+		s.pulled[uri] = DocumentDiagnosticReport{
+			Kind:     DiagnosticReportFull,
+			ResultID: report.ResultID,
+			Items:    report.Items,
+		}
+		return append([]Diagnostic(nil), report.Items...)
+	}
+}
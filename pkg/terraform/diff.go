@@ -0,0 +1,360 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// diffOp is a single line in an edit script. a and b are the indexes of the
+// line in the old and new text; for insertions a is the position in the old
+// text where the line is inserted, and likewise b for deletions.
+type diffOp struct {
+	kind byte
+	a, b int
+}
+
+// UnifiedDiff returns a unified diff between two texts, or an empty string if
+// they are equal
+func UnifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	aLines, bLines := splitLines(a), splitLines(b)
+	ops := diffLines(aLines, bLines)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for _, hunk := range groupHunks(ops) {
+		aStart, aLen, bStart, bLen := hunk[0].a, 0, hunk[0].b, 0
+		for _, op := range hunk {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		if aLen > 0 {
+			aStart++
+		}
+		if bLen > 0 {
+			bStart++
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+
+		for _, op := range hunk {
+			line := ""
+			switch op.kind {
+			case '+':
+				line = bLines[op.b]
+			default:
+				line = aLines[op.a]
+			}
+			out.WriteByte(op.kind)
+			out.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return out.String()
+}
+
+// splitLines splits text into lines that keep their line endings
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script with the linear space variant of
+// Myers' algorithm, which finds the middle of the edit path and recurses on
+// both halves. Within each change, deletions come before insertions.
+func diffLines(a, b []string) []diffOp {
+	// Compare lines as small integers
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+
+	d := &differ{a: intern(a), b: intern(b)}
+	d.diff(0, len(a), 0, len(b))
+	return orderChanges(d.ops)
+}
+
+// differ builds an edit script between two sequences of interned lines
+type differ struct {
+	a, b []int
+	ops  []diffOp
+}
+
+// diff appends the edit script from a[aLo:aHi] to b[bLo:bHi]
+func (d *differ) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{kind: ' ', a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aEnd, bEnd := aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aEnd:
+		d.insert(aLo, bLo, bEnd)
+	case bLo == bEnd:
+		d.delete(aLo, aEnd, bLo)
+	default:
+		x, y, ok := d.middle(aLo, aEnd, bLo, bEnd)
+		if ok {
+			d.diff(aLo, x, bLo, y)
+			d.diff(x, aEnd, y, bEnd)
+		} else {
+			d.delete(aLo, aEnd, bLo)
+			d.insert(aEnd, bLo, bEnd)
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.ops = append(d.ops, diffOp{kind: ' ', a: aEnd + i, b: bEnd + i})
+	}
+}
+
+func (d *differ) delete(aLo, aHi, b int) {
+	for x := aLo; x < aHi; x++ {
+		d.ops = append(d.ops, diffOp{kind: '-', a: x, b: b})
+	}
+}
+
+func (d *differ) insert(a, bLo, bHi int) {
+	for y := bLo; y < bHi; y++ {
+		d.ops = append(d.ops, diffOp{kind: '+', a: a, b: y})
+	}
+}
+
+// middle searches forwards and backwards at once for a point on a shortest
+// edit path from a[aLo:aHi] to b[bLo:bHi], and reports false if there is no
+// point that splits the problem
+func (d *differ) middle(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// With an odd delta the paths meet while extending forwards,
+	// otherwise while extending backwards
+	odd := delta%2 != 0
+
+	// Diagonals that run off the edge are not extended again
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if bk := offset + delta - k; bk >= 0 && bk < len(backward) && backward[bk] != -1 && x >= n-backward[bk] {
+					return d.split(aLo, aHi, bLo, bHi, x, y)
+				}
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if fk := offset + delta - k; fk >= 0 && fk < len(forward) && forward[fk] != -1 {
+					fx := forward[fk]
+					fy := fx - (fk - offset)
+					if fx >= n-x {
+						return d.split(aLo, aHi, bLo, bHi, fx, fy)
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// split turns a point relative to the start of the ranges into absolute
+// indexes, rejecting the corners, which would not make progress
+func (d *differ) split(aLo, aHi, bLo, bHi, x, y int) (int, int, bool) {
+	x, y = aLo+x, bLo+y
+	if (x == aLo && y == bLo) || (x == aHi && y == bHi) {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// orderChanges moves the deletions of each run of changes before its
+// insertions, so that diffs read as old lines followed by new ones
+func orderChanges(ops []diffOp) []diffOp {
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start, a, b := i, ops[i].a, ops[i].b
+		deleted, inserted := 0, 0
+		for ; i < len(ops) && ops[i].kind != ' '; i++ {
+			if ops[i].kind == '-' {
+				deleted++
+			} else {
+				inserted++
+			}
+		}
+
+		for j := 0; j < deleted; j++ {
+			ops[start+j] = diffOp{kind: '-', a: a + j, b: b}
+		}
+		for j := 0; j < inserted; j++ {
+			ops[start+deleted+j] = diffOp{kind: '+', a: a + deleted, b: b + j}
+		}
+	}
+	return ops
+}
+
+// groupHunks splits an edit script into hunks of changes surrounded by up to
+// diffContext unchanged lines, merging changes that are close together
+func groupHunks(ops []diffOp) [][]diffOp {
+	var hunks [][]diffOp
+
+	i := 0
+	for i < len(ops) {
+		// Find the next change
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk while the gap between changes is small enough.
+		// Larger gaps leave room for both hunks' context, so hunks never
+		// share lines.
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			gap := end
+			for gap < len(ops) && ops[gap].kind == ' ' {
+				gap++
+			}
+			if gap < len(ops) && gap-end <= 2*diffContext {
+				end = gap
+				continue
+			}
+			break
+		}
+
+		stop := end + diffContext
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		hunks = append(hunks, ops[start:stop])
+		i = end
+	}
+
+	return hunks
+}
+
+// lineEdits describes the change from a to b as text edits that each replace
+// a run of whole lines
+func lineEdits(a, b string) []TextEdit {
+	aLines, bLines := splitLines(a), splitLines(b)
+	ops := diffLines(aLines, bLines)
+
+	var edits []TextEdit
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start, end := ops[i].a, ops[i].a
+		var text strings.Builder
+		for ; i < len(ops) && ops[i].kind != ' '; i++ {
+			switch ops[i].kind {
+			case '-':
+				end = ops[i].a + 1
+			case '+':
+				text.WriteString(bLines[ops[i].b])
+			}
+		}
+
+		edits = append(edits, TextEdit{
+			Range: Range{
+				Start: Position{Line: start},
+				End:   Position{Line: end},
+			},
+			NewText: text.String(),
+		})
+	}
+	return edits
+}
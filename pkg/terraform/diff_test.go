@@ -0,0 +1,156 @@
+package terraform
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm"
+
+	want := `--- main.tf
++++ main.tf
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+\ No newline at end of file
+`
+
+	if got := UnifiedDiff("main.tf", "main.tf", a, b); got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_Equal(t *testing.T) {
+	if got := UnifiedDiff("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("Expected empty diff, got: %q", got)
+	}
+}
+
+func TestUnifiedDiff_MergesNearbyChanges(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n"
+	b := "1\nX\n3\n4\n5\n6\nY\n8\n"
+
+	want := `--- a
++++ b
+@@ -1,8 +1,8 @@
+ 1
+-2
++X
+ 3
+ 4
+ 5
+ 6
+-7
++Y
+ 8
+`
+
+	if got := UnifiedDiff("a", "b", a, b); got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffLines_Shortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		ops := diffLines(a, b)
+
+		// The script must turn a into b, with no more edits than needed
+		var got []string
+		edits := 0
+		x := 0
+		for _, op := range ops {
+			switch op.kind {
+			case ' ':
+				if a[op.a] != b[op.b] || op.a != x {
+					t.Fatalf("%v -> %v: bad context op %+v", a, b, op)
+				}
+				got = append(got, a[op.a])
+				x++
+			case '-':
+				if op.a != x {
+					t.Fatalf("%v -> %v: bad delete op %+v", a, b, op)
+				}
+				x++
+				edits++
+			case '+':
+				got = append(got, b[op.b])
+				edits++
+			}
+		}
+		if strings.Join(got, "") != strings.Join(b, "") || x != len(a) {
+			t.Fatalf("%v -> %v: script produces %v", a, b, got)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("%v -> %v: expected %d edits, got: %d", a, b, want, edits)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func TestUnifiedDiff_LargeRewrite(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&a, "line %d\n", i)
+		fmt.Fprintf(&b, "  line %d\n", i)
+	}
+
+	diff := UnifiedDiff("a", "b", a.String(), b.String())
+	if removed, added := strings.Count(diff, "\n-line"), strings.Count(diff, "\n+  line"); removed != 5000 || added != 5000 {
+		t.Errorf("Expected 5000 removed and added lines, got: %d, %d", removed, added)
+	}
+}
+
+func TestLineEdits(t *testing.T) {
+	a := "a\nb\nc\nd\n"
+	b := "a\nB\nc\nd\ne\n"
+
+	edits := lineEdits(a, b)
+	if len(edits) != 2 {
+		t.Fatalf("Expected 2 edits, got: %+v", edits)
+	}
+	if got, err := ApplyTextEdits(a, edits); err != nil || got != b {
+		t.Errorf("Expected edits to produce %q, got: %q (%v)", b, got, err)
+	}
+	if edits[1].Range.Start.Line != 4 || edits[1].Range.End.Line != 4 {
+		t.Errorf("Expected an insertion at line 4, got: %+v", edits[1].Range)
+	}
+}
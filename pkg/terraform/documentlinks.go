@@ -0,0 +1,137 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp"
+)
+
+// DocumentLinks gets the links in a document, such as the registry
+// documentation of providers and modules, ordered by position
+func (c *Client) DocumentLinks(ctx context.Context, uri, content string) ([]DocumentLink, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/documentLink", DocumentLinkParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document links: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("document link error: %s", resp.Error.Message)
+	}
+
+	var links []DocumentLink
+	if err := resp.UnmarshalResult(&links); err != nil {
+		return nil, fmt.Errorf("failed to decode document links: %w", err)
+	}
+
+	sort.SliceStable(links, func(i, j int) bool {
+		return positionBefore(links[i].Range.Start, links[j].Range.Start)
+	})
+	return links, nil
+}
+
+// ResolveDocumentLink asks the server for the target of a link. Links that
+// already have a target, and links the server cannot resolve, are returned
+// unchanged.
+func (c *Client) ResolveDocumentLink(ctx context.Context, link DocumentLink) (*DocumentLink, error) {
+	if link.Target != "" {
+		return &link, nil
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "documentLink/resolve", link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve document link: %w", err)
+	}
+
+	if resp.Error != nil {
+		if resp.Error.Code == lsp.CodeMethodNotFound {
+			return &link, nil
+		}
+		return nil, fmt.Errorf("document link resolve error: %s", resp.Error.Message)
+	}
+
+	resolved := link
+	if err := resp.UnmarshalResult(&resolved); err != nil {
+		return nil, fmt.Errorf("failed to decode document link: %w", err)
+	}
+
+	return &resolved, nil
+}
+
+// BlockLinks lists the resource, data source and module blocks of a
+// document with the documentation URL of each. A block gets the first link
+// in its header, or failing that the first link in its body, such as the
+// one on a module source.
+func (c *Client) BlockLinks(ctx context.Context, uri, content string) ([]BlockLink, error) {
+	links, err := c.DocumentLinks(ctx, uri, content)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := newLineIndex(content)
+	blocks := []BlockLink{}
+
+	for _, node := range scanHCL(content) {
+		if !node.block {
+			continue
+		}
+
+		blockType, labels := hclBlockHeader(content, node)
+		block := BlockLink{
+			Kind:  blockType,
+			Range: Range{Start: idx.position(node.start), End: idx.position(node.end)},
+		}
+		switch {
+		case (blockType == "resource" || blockType == "data") && len(labels) == 2:
+			block.Type, block.Name = labels[0], labels[1]
+		case blockType == "module" && len(labels) == 1:
+			block.Name = labels[0]
+			if source, ok := hclAttribute(content, node, "source"); ok {
+				if unquoted, err := strconv.Unquote(source); err == nil {
+					source = unquoted
+				}
+				block.Source = source
+			}
+		default:
+			continue
+		}
+
+		var header, body *DocumentLink
+		for i, link := range links {
+			start, err := idx.offset(link.Range.Start)
+			if err != nil || start < node.start || start >= node.end {
+				continue
+			}
+			if start < node.bodyStart && header == nil {
+				header = &links[i]
+			} else if start >= node.bodyStart && body == nil {
+				body = &links[i]
+			}
+		}
+		if header == nil {
+			header = body
+		}
+
+		if header != nil {
+			resolved, err := c.ResolveDocumentLink(ctx, *header)
+			if err != nil {
+				return nil, err
+			}
+			block.URL = resolved.Target
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,57 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClient_BlockLinks(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/documentLink", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`[
+			{"range": {"start": {"line": 5, "character": 11}, "end": {"line": 5, "character": 46}}, "data": {"id": 2}},
+			{"range": {"start": {"line": 0, "character": 9}, "end": {"line": 0, "character": 23}},
+			 "target": "https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/instance"}
+		]`), nil
+	})
+	server.Handle("documentLink/resolve", func(params json.RawMessage) (interface{}, error) {
+		var link DocumentLink
+		if err := json.Unmarshal(params, &link); err != nil {
+			return nil, err
+		}
+		link.Target = "https://registry.terraform.io/modules/terraform-aws-modules/vpc/aws/latest"
+		return link, nil
+	})
+
+	content := `resource "aws_instance" "web" {
+  ami = "ami-123"
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+
+data "aws_ami" "ubuntu" {}
+
+locals {}
+`
+	blocks, err := client.BlockLinks(testContext(t), "file:///workspace/main.tf", content)
+	if err != nil {
+		t.Fatalf("Failed to get block links: %v", err)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got: %+v", blocks)
+	}
+	if b := blocks[0]; b.Kind != "resource" || b.Type != "aws_instance" || b.Name != "web" ||
+		b.URL != "https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/instance" {
+		t.Errorf("Unexpected resource: %+v", b)
+	}
+	if b := blocks[1]; b.Kind != "module" || b.Name != "vpc" || b.Source != "terraform-aws-modules/vpc/aws" ||
+		b.URL != "https://registry.terraform.io/modules/terraform-aws-modules/vpc/aws/latest" || b.Range.Start.Line != 4 {
+		t.Errorf("Unexpected module: %+v", b)
+	}
+	if b := blocks[2]; b.Kind != "data" || b.Type != "aws_ami" || b.Name != "ubuntu" || b.URL != "" {
+		t.Errorf("Unexpected data source: %+v", b)
+	}
+}
@@ -0,0 +1,376 @@
+package terraform
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// defaultMaxOpenDocuments is the number of documents kept open in
+// terraform-ls before the least recently used one is closed
+const defaultMaxOpenDocuments = 64
+
+// openDocument is a document the server currently has open
+type openDocument struct {
+	version  int
+	hash     [sha256.Size]byte
+	content  string
+	lastUsed uint64
+}
+
+// documentLock serializes the use of a document. refs counts the holder and
+// the waiters, so that the lock can be dropped once nobody needs it.
+type documentLock struct {
+	held chan struct{}
+	refs int
+}
+
+// documentStore tracks the documents opened in the server, so that repeated
+// calls for the same file send didChange with increasing versions instead of
+// opening it again
+type documentStore struct {
+	mu       sync.Mutex
+	docs     map[string]*openDocument
+	locks    map[string]*documentLock
+	maxOpen  int
+	useCount uint64
+}
+
+func newDocumentStore(maxOpen int) *documentStore {
+	return &documentStore{
+		docs:    make(map[string]*openDocument),
+		locks:   make(map[string]*documentLock),
+		maxOpen: maxOpen,
+	}
+}
+
+// LockDocument waits until no other caller uses a document and returns the
+// function that releases it. Holding the lock from syncing the content
+// through the requests that rely on it keeps concurrent calls for the same
+// document from replacing the content in between. Documents in use are not
+// evicted, and are left alone when other documents are synced from disk.
+func (c *Client) LockDocument(ctx context.Context, uri string) (func(), error) {
+	lock := c.documents.ref(uri)
+
+	select {
+	case lock.held <- struct{}{}:
+	case <-ctx.Done():
+		c.documents.unref(uri, lock)
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-lock.held
+		c.documents.unref(uri, lock)
+	}, nil
+}
+
+// tryLockDocument is like LockDocument, but gives up at once if the
+// document is in use
+func (c *Client) tryLockDocument(uri string) (func(), bool) {
+	lock := c.documents.ref(uri)
+
+	select {
+	case lock.held <- struct{}{}:
+	default:
+		c.documents.unref(uri, lock)
+		return nil, false
+	}
+
+	return func() {
+		<-lock.held
+		c.documents.unref(uri, lock)
+	}, true
+}
+
+// ref returns the lock of uri, counting the caller as a user
+func (s *documentStore) ref(uri string) *documentLock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock := s.locks[uri]
+	if lock == nil {
+		lock = &documentLock{held: make(chan struct{}, 1)}
+		s.locks[uri] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// unref drops a use of the lock of uri, forgetting the lock with the last one
+func (s *documentStore) unref(uri string, lock *documentLock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(s.locks, uri)
+	}
+}
+
+// SetMaxOpenDocuments sets how many documents are kept open in terraform-ls.
+// When the limit is reached, the least recently used document is closed.
+func (c *Client) SetMaxOpenDocuments(n int) {
+	c.documents.mu.Lock()
+	defer c.documents.mu.Unlock()
+	c.documents.maxOpen = n
+}
+
+// DocumentVersion returns the version of an open document, or 0 if the
+// document is not open
+func (c *Client) DocumentVersion(uri string) int {
+	c.documents.mu.Lock()
+	defer c.documents.mu.Unlock()
+
+	if doc := c.documents.docs[uri]; doc != nil {
+		return doc.version
+	}
+	return 0
+}
+
+// documentContent returns the content last synced for an open document
+func (c *Client) documentContent(uri string) (string, bool) {
+	c.documents.mu.Lock()
+	defer c.documents.mu.Unlock()
+
+	if doc := c.documents.docs[uri]; doc != nil {
+		return doc.content, true
+	}
+	return "", false
+}
+
+// openDocument makes the server's view of a document match content. The
+// document is opened on first use; afterwards didChange is sent only when
+// the content differs from the last synced version. It reports whether the
+// server was sent anything.
+func (c *Client) openDocument(ctx context.Context, uri, content string) (bool, error) {
+	store := c.documents
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.useCount++
+	hash := sha256.Sum256([]byte(content))
+	syncOpts := c.textDocumentSync()
+
+	doc, exists := store.docs[uri]
+	if !exists {
+		if err := c.evictDocumentsLocked(); err != nil {
+			return false, err
+		}
+
+		if syncOpts.OpenClose {
+			err := c.lspClient.SendNotification("textDocument/didOpen", DidOpenTextDocumentParams{
+				TextDocument: TextDocumentItem{
+					URI:        uri,
+					LanguageID: "terraform",
+					Version:    1,
+					Text:       content,
+				},
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		store.docs[uri] = &openDocument{
+			version:  1,
+			hash:     hash,
+			content:  content,
+			lastUsed: store.useCount,
+		}
+		return syncOpts.OpenClose, nil
+	}
+
+	doc.lastUsed = store.useCount
+	if doc.hash == hash {
+		return false, nil
+	}
+
+	var changes []TextDocumentContentChangeEvent
+	switch syncOpts.Change {
+	case TextDocumentSyncIncremental:
+		changes = []TextDocumentContentChangeEvent{incrementalChange(doc.content, content)}
+	case TextDocumentSyncFull:
+		changes = []TextDocumentContentChangeEvent{{Text: content}}
+	}
+
+	if len(changes) > 0 {
+		err := c.lspClient.SendNotification("textDocument/didChange", DidChangeTextDocumentParams{
+			TextDocument: VersionedTextDocumentIdentifier{
+				URI:     uri,
+				Version: doc.version + 1,
+			},
+			ContentChanges: changes,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	doc.version++
+	doc.hash = hash
+	doc.content = content
+
+	return len(changes) > 0, nil
+}
+
+// RefreshDocuments syncs the documents open in the server, except the given
+// ones, with the files on disk, so that edits the server computes next
+// apply to the files as they are now. Documents that can no longer be read
+// are closed. Documents locked by another caller are skipped; edits
+// computed from their content are caught as stale when applied.
+func (c *Client) RefreshDocuments(ctx context.Context, except ...string) error {
+	c.documents.mu.Lock()
+	uris := make([]string, 0, len(c.documents.docs))
+	for uri := range c.documents.docs {
+		if !slices.Contains(except, uri) {
+			uris = append(uris, uri)
+		}
+	}
+	c.documents.mu.Unlock()
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		if err := c.refreshDocument(ctx, uri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshDocument syncs an open document with its file on disk, unless the
+// document is locked
+func (c *Client) refreshDocument(ctx context.Context, uri string) error {
+	unlock, ok := c.tryLockDocument(uri)
+	if !ok {
+		return nil
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(URIToPath(uri))
+	if err != nil {
+		return c.CloseDocument(uri)
+	}
+
+	if content, ok := c.documentContent(uri); !ok || content == string(data) {
+		return nil
+	}
+	if _, err := c.openDocument(ctx, uri, string(data)); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", URIToPath(uri), err)
+	}
+	return nil
+}
+
+// CloseDocument closes a document in the server if it is open
+func (c *Client) CloseDocument(uri string) error {
+	c.documents.mu.Lock()
+	defer c.documents.mu.Unlock()
+
+	return c.closeDocumentLocked(uri)
+}
+
+func (c *Client) closeDocumentLocked(uri string) error {
+	if _, exists := c.documents.docs[uri]; !exists {
+		return nil
+	}
+	delete(c.documents.docs, uri)
+
+	if !c.textDocumentSync().OpenClose {
+		return nil
+	}
+
+	err := c.lspClient.SendNotification("textDocument/didClose", DidCloseTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to close %s: %w", uri, err)
+	}
+	return nil
+}
+
+// evictDocumentsLocked closes least recently used documents until there is
+// room to open another one. Locked documents are kept open, even if that
+// leaves more documents open than the limit.
+func (c *Client) evictDocumentsLocked() error {
+	store := c.documents
+	for store.maxOpen > 0 && len(store.docs) >= store.maxOpen {
+		var oldestURI string
+		var oldest *openDocument
+		for uri, doc := range store.docs {
+			if store.locks[uri] != nil {
+				continue
+			}
+			if oldest == nil || doc.lastUsed < oldest.lastUsed {
+				oldestURI, oldest = uri, doc
+			}
+		}
+		if oldest == nil {
+			return nil
+		}
+		if err := c.closeDocumentLocked(oldestURI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textDocumentSync returns the sync options announced by the server. Before
+// initialization, or if the server did not say, documents are opened and
+// closed and changes are sent in full.
+func (c *Client) textDocumentSync() TextDocumentSyncOptions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capabilities.TextDocumentSync == nil {
+		return TextDocumentSyncOptions{OpenClose: true, Change: TextDocumentSyncFull}
+	}
+	return *c.capabilities.TextDocumentSync
+}
+
+// incrementalChange describes the change from oldText to newText as a single
+// range replacement covering everything between their common prefix and
+// common suffix
+func incrementalChange(oldText, newText string) TextDocumentContentChangeEvent {
+	prefix := 0
+	for prefix < len(oldText) && prefix < len(newText) && oldText[prefix] == newText[prefix] {
+		prefix++
+	}
+	// Do not split a UTF-8 sequence or a CRLF line break
+	for prefix > 0 && !isChangeBoundary(oldText, prefix) {
+		prefix--
+	}
+
+	suffix := 0
+	for suffix < len(oldText)-prefix && suffix < len(newText)-prefix &&
+		oldText[len(oldText)-1-suffix] == newText[len(newText)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !isChangeBoundary(oldText, len(oldText)-suffix) {
+		suffix--
+	}
+
+	idx := newLineIndex(oldText)
+	return TextDocumentContentChangeEvent{
+		Range: &Range{
+			Start: idx.position(prefix),
+			End:   idx.position(len(oldText) - suffix),
+		},
+		Text: newText[prefix : len(newText)-suffix],
+	}
+}
+
+// isChangeBoundary reports whether a change may start or end at offset
+// without splitting a UTF-8 sequence or a CRLF line break
+func isChangeBoundary(text string, offset int) bool {
+	if offset <= 0 || offset >= len(text) {
+		return true
+	}
+	return utf8.RuneStart(text[offset]) && !(text[offset-1] == '\r' && text[offset] == '\n')
+}
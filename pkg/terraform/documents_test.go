@@ -0,0 +1,219 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClient_OpenDocumentTracksVersions(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := testContext(t)
+	uri := "file:///workspace/main.tf"
+
+	for _, content := range []string{"a = 1\n", "a = 1\n", "a = 2\n"} {
+		if _, err := client.openDocument(ctx, uri, content); err != nil {
+			t.Fatalf("Failed to open document: %v", err)
+		}
+	}
+
+	roundTrip(t, server, client)
+
+	if got := len(server.Received("textDocument/didOpen")); got != 1 {
+		t.Errorf("Expected 1 didOpen, got: %d", got)
+	}
+
+	changes := server.Received("textDocument/didChange")
+	if len(changes) != 1 {
+		t.Fatalf("Expected 1 didChange, got: %d", len(changes))
+	}
+
+	var params DidChangeTextDocumentParams
+	json.Unmarshal(changes[0].Params, &params)
+	if params.TextDocument.Version != 2 {
+		t.Errorf("Expected version 2, got: %d", params.TextDocument.Version)
+	}
+	if len(params.ContentChanges) != 1 || params.ContentChanges[0].Range != nil || params.ContentChanges[0].Text != "a = 2\n" {
+		t.Errorf("Expected a full content change, got: %+v", params.ContentChanges)
+	}
+	if client.DocumentVersion(uri) != 2 {
+		t.Errorf("Expected document version 2, got: %d", client.DocumentVersion(uri))
+	}
+}
+
+func TestClient_OpenDocumentIncrementalSync(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`{"capabilities": {"textDocumentSync": {"openClose": true, "change": 2}}}`), nil
+	})
+
+	ctx := testContext(t)
+	if err := client.Initialize(ctx, "/workspace"); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	uri := "file:///workspace/main.tf"
+	client.openDocument(ctx, uri, "locals {\n  a = 1\n}\n")
+	client.openDocument(ctx, uri, "locals {\n  a = 42\n}\n")
+
+	roundTrip(t, server, client)
+
+	changes := server.Received("textDocument/didChange")
+	if len(changes) != 1 {
+		t.Fatalf("Expected 1 didChange, got: %d", len(changes))
+	}
+
+	var params DidChangeTextDocumentParams
+	json.Unmarshal(changes[0].Params, &params)
+	change := params.ContentChanges[0]
+	if change.Range == nil {
+		t.Fatal("Expected an incremental change with a range")
+	}
+	want := Range{Start: Position{Line: 1, Character: 6}, End: Position{Line: 1, Character: 7}}
+	if *change.Range != want || change.Text != "42" {
+		t.Errorf("Expected %+v -> %q, got: %+v -> %q", want, "42", *change.Range, change.Text)
+	}
+}
+
+func TestClient_EvictsLeastRecentlyUsedDocument(t *testing.T) {
+	server, client := newTestClient(t)
+	client.SetMaxOpenDocuments(2)
+	ctx := testContext(t)
+
+	client.openDocument(ctx, "file:///workspace/a.tf", "")
+	client.openDocument(ctx, "file:///workspace/b.tf", "")
+	client.openDocument(ctx, "file:///workspace/a.tf", "")
+	client.openDocument(ctx, "file:///workspace/c.tf", "")
+
+	roundTrip(t, server, client)
+
+	closes := server.Received("textDocument/didClose")
+	if len(closes) != 1 {
+		t.Fatalf("Expected 1 didClose, got: %d", len(closes))
+	}
+
+	var params DidCloseTextDocumentParams
+	json.Unmarshal(closes[0].Params, &params)
+	if params.TextDocument.URI != "file:///workspace/b.tf" {
+		t.Errorf("Expected b.tf to be closed, got: %s", params.TextDocument.URI)
+	}
+	if client.DocumentVersion("file:///workspace/b.tf") != 0 {
+		t.Error("Expected b.tf to no longer be open")
+	}
+}
+
+func TestClient_LockDocument(t *testing.T) {
+	server, client := newTestClient(t)
+	client.SetMaxOpenDocuments(1)
+	ctx := testContext(t)
+	uri := "file:///workspace/a.tf"
+
+	unlock, err := client.LockDocument(ctx, uri)
+	if err != nil {
+		t.Fatalf("Failed to lock document: %v", err)
+	}
+	client.openDocument(ctx, uri, "")
+
+	// A second caller waits for the lock
+	locked := make(chan func())
+	go func() {
+		unlock, err := client.LockDocument(ctx, uri)
+		if err != nil {
+			t.Errorf("Failed to lock document: %v", err)
+		}
+		locked <- unlock
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.LockDocument(waitCtx, uri); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected to time out waiting for the lock, got: %v", err)
+	}
+	select {
+	case <-locked:
+		t.Fatal("Expected the lock to be held")
+	default:
+	}
+
+	// The locked document is not evicted to make room for another one
+	client.openDocument(ctx, "file:///workspace/b.tf", "")
+	roundTrip(t, server, client)
+	if got := len(server.Received("textDocument/didClose")); got != 0 {
+		t.Errorf("Expected the locked document to stay open, got %d didClose", got)
+	}
+
+	unlock()
+	(<-locked)()
+
+	client.documents.mu.Lock()
+	defer client.documents.mu.Unlock()
+	if len(client.documents.locks) != 0 {
+		t.Errorf("Expected unused locks to be dropped, got: %d", len(client.documents.locks))
+	}
+}
+
+func TestIncrementalChange(t *testing.T) {
+	cases := []struct {
+		name     string
+		old, new string
+		want     TextDocumentContentChangeEvent
+	}{
+		{
+			name: "insertion",
+			old:  "ab\n",
+			new:  "aXb\n",
+			want: TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 1}, End: Position{0, 1}}, Text: "X"},
+		},
+		{
+			name: "deletion across lines",
+			old:  "a\nb\nc\n",
+			new:  "a\nc\n",
+			want: TextDocumentContentChangeEvent{Range: &Range{Start: Position{1, 0}, End: Position{2, 0}}, Text: ""},
+		},
+		{
+			name: "utf-16 columns",
+			old:  "x = \"😀a\"\n",
+			new:  "x = \"😀b\"\n",
+			want: TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 7}, End: Position{0, 8}}, Text: "b"},
+		},
+		{
+			name: "multi-byte characters sharing a prefix",
+			old:  "é",
+			new:  "è",
+			want: TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 0}, End: Position{0, 1}}, Text: "è"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := incrementalChange(tc.old, tc.new)
+			if *got.Range != *tc.want.Range || got.Text != tc.want.Text {
+				t.Errorf("Expected %+v -> %q, got: %+v -> %q", *tc.want.Range, tc.want.Text, *got.Range, got.Text)
+			}
+
+			applied, err := ApplyTextEdits(tc.old, []TextEdit{{Range: *got.Range, NewText: got.Text}})
+			if err != nil || applied != tc.new {
+				t.Errorf("Expected change to produce %q, got: %q (%v)", tc.new, applied, err)
+			}
+		})
+	}
+}
+
+func TestTextDocumentSyncOptions_Unmarshal(t *testing.T) {
+	var caps ServerCapabilities
+	if err := json.Unmarshal([]byte(`{"textDocumentSync": 1}`), &caps); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if !caps.TextDocumentSync.OpenClose || caps.TextDocumentSync.Change != TextDocumentSyncFull {
+		t.Errorf("Expected full sync with open/close, got: %+v", caps.TextDocumentSync)
+	}
+
+	if err := json.Unmarshal([]byte(`{"textDocumentSync": {"openClose": true, "change": 2}}`), &caps); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if caps.TextDocumentSync.Change != TextDocumentSyncIncremental {
+		t.Errorf("Expected incremental sync, got: %+v", caps.TextDocumentSync)
+	}
+}
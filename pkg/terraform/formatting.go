@@ -0,0 +1,277 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultFormattingOptions returns the options used when the caller has no
+// preference. terraform fmt ignores the tab size and spaces, but the
+// protocol requires them.
+func DefaultFormattingOptions() FormattingOptions {
+	return FormattingOptions{
+		TabSize:      2,
+		InsertSpaces: true,
+	}
+}
+
+// FormatDocument formats a Terraform document and returns both the edits
+// suggested by the server and the resulting content. terraform fmt ignores
+// the trailing whitespace and final newline options, so they are applied to
+// its result here, and the edits then cover both.
+func (c *Client) FormatDocument(ctx context.Context, uri, content string, opts FormattingOptions) (*FormatResult, error) {
+	result, err := c.format(ctx, "textDocument/formatting", uri, content, DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Options: opts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyWhitespaceOptions(result, content, opts, 0, -1), nil
+}
+
+// FormatRange formats the lines of a document covered by r. If the server
+// does not support range formatting, the whole document is formatted and only
+// the changes touching those lines are kept. The whitespace options apply to
+// the same lines; the final newline options only if r reaches the last line.
+func (c *Client) FormatRange(ctx context.Context, uri, content string, r Range, opts FormattingOptions) (*FormatResult, error) {
+	last := lastRangeLine(r)
+
+	if !c.rangeFormattingSupported() {
+		result, err := c.format(ctx, "textDocument/formatting", uri, content, DocumentFormattingParams{
+			TextDocument: TextDocumentIdentifier{
+				URI: uri,
+			},
+			Options: opts,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var edits []TextEdit
+		for _, edit := range lineEdits(content, result.Formatted) {
+			for _, edit := range splitLineEdit(edit) {
+				if editTouchesLines(edit, r.Start.Line, last) {
+					edits = append(edits, edit)
+				}
+			}
+		}
+
+		formatted, err := ApplyTextEdits(content, edits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply text edits: %w", err)
+		}
+		return applyWhitespaceOptions(&FormatResult{
+			URI:       uri,
+			Edits:     edits,
+			Formatted: formatted,
+		}, content, opts, r.Start.Line, last), nil
+	}
+
+	result, err := c.format(ctx, "textDocument/rangeFormatting", uri, content, DocumentRangeFormattingParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Range:   r,
+		Options: opts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyWhitespaceOptions(result, content, opts, r.Start.Line, last), nil
+}
+
+// FormatOnType formats a document after ch was typed at the given position.
+// If the server does not format on that character, the line containing the
+// position is range formatted instead. The whitespace options apply to that
+// line.
+func (c *Client) FormatOnType(ctx context.Context, uri, content string, line, character int, ch string, opts FormattingOptions) (*FormatResult, error) {
+	if !c.onTypeFormattingTrigger(ch) {
+		return c.FormatRange(ctx, uri, content, Range{
+			Start: Position{Line: line},
+			End:   Position{Line: line + 1},
+		}, opts)
+	}
+
+	result, err := c.format(ctx, "textDocument/onTypeFormatting", uri, content, DocumentOnTypeFormattingParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+		Ch:      ch,
+		Options: opts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyWhitespaceOptions(result, content, opts, line, line), nil
+}
+
+// format sends a formatting request and applies the returned edits to content
+func (c *Client) format(ctx context.Context, method, uri, content string, params interface{}) (*FormatResult, error) {
+	// Open document
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format document: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("format error: %s", resp.Error.Message)
+	}
+
+	var textEdits []TextEdit
+	if err := resp.UnmarshalResult(&textEdits); err != nil {
+		return nil, fmt.Errorf("failed to decode text edits: %w", err)
+	}
+
+	formatted, err := ApplyTextEdits(content, textEdits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply text edits: %w", err)
+	}
+
+	return &FormatResult{
+		URI:       uri,
+		Edits:     textEdits,
+		Formatted: formatted,
+	}, nil
+}
+
+// applyWhitespaceOptions applies the trailing whitespace and final newline
+// options to a formatting result of content, for the lines first to last of
+// content, inclusive; a negative last means the end of the document. The
+// final newline options only apply when the lines reach the end. If this
+// changes anything, the edits are recomputed from content.
+func applyWhitespaceOptions(result *FormatResult, content string, opts FormattingOptions, first, last int) *FormatResult {
+	if !opts.TrimTrailingWhitespace && !opts.InsertFinalNewline && !opts.TrimFinalNewlines {
+		return result
+	}
+
+	// Formatting within the lines may have added or removed lines
+	lines := splitLines(result.Formatted)
+	if last >= 0 {
+		last += len(lines) - len(splitLines(content))
+	}
+	if last < 0 || last >= len(lines)-1 {
+		last = len(lines) - 1
+	}
+	toEnd := last == len(lines)-1
+
+	if opts.TrimTrailingWhitespace {
+		for i := max(first, 0); i <= last; i++ {
+			text := strings.TrimRight(lines[i], "\r\n")
+			lines[i] = strings.TrimRight(text, " \t") + lines[i][len(text):]
+		}
+	}
+	formatted := strings.Join(lines, "")
+
+	if toEnd && opts.TrimFinalNewlines {
+		if text := strings.TrimRight(formatted, "\r\n"); text != formatted {
+			newline := "\n"
+			if strings.HasPrefix(formatted[len(text):], "\r\n") {
+				newline = "\r\n"
+			}
+			formatted = text + newline
+		}
+	}
+	if toEnd && opts.InsertFinalNewline && formatted != "" && !strings.HasSuffix(formatted, "\n") {
+		formatted += "\n"
+	}
+
+	if formatted == result.Formatted {
+		return result
+	}
+	return &FormatResult{
+		URI:       result.URI,
+		Edits:     lineEdits(content, formatted),
+		Formatted: formatted,
+	}
+}
+
+// rangeFormattingSupported reports whether the server accepts
+// textDocument/rangeFormatting
+func (c *Client) rangeFormattingSupported() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return capabilityEnabled(c.capabilities.DocumentRangeFormattingProvider)
+}
+
+// onTypeFormattingTrigger reports whether the server formats on typing ch
+func (c *Client) onTypeFormattingTrigger(ch string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	provider := c.capabilities.DocumentOnTypeFormattingProvider
+	if provider == nil {
+		return false
+	}
+	if provider.FirstTriggerCharacter == ch {
+		return true
+	}
+	for _, trigger := range provider.MoreTriggerCharacter {
+		if trigger == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilityEnabled reports whether a provider announced as either a boolean
+// or an options object is enabled
+func capabilityEnabled(raw json.RawMessage) bool {
+	value := string(raw)
+	return value != "" && value != "false" && value != "null"
+}
+
+// splitLineEdit splits a line edit that rewrites n lines into n lines into
+// one edit per line, so that a change to neighbouring lines can be kept
+// for some of them only. Other edits are returned whole.
+func splitLineEdit(edit TextEdit) []TextEdit {
+	start := edit.Range.Start.Line
+	lines := splitLines(edit.NewText)
+	if len(lines) < 2 || len(lines) != edit.Range.End.Line-start {
+		return []TextEdit{edit}
+	}
+
+	edits := make([]TextEdit, len(lines))
+	for i, line := range lines {
+		edits[i] = TextEdit{
+			Range: Range{
+				Start: Position{Line: start + i},
+				End:   Position{Line: start + i + 1},
+			},
+			NewText: line,
+		}
+	}
+	return edits
+}
+
+// lastRangeLine returns the last line a range covers. A range ending at the
+// start of a line, such as a whole line selection, does not cover that line.
+func lastRangeLine(r Range) int {
+	if r.End.Character == 0 && r.End.Line > r.Start.Line {
+		return r.End.Line - 1
+	}
+	return r.End.Line
+}
+
+// editTouchesLines reports whether a line edit changes or inserts at any line
+// from first to last inclusive
+func editTouchesLines(edit TextEdit, first, last int) bool {
+	start, end := edit.Range.Start.Line, edit.Range.End.Line-1
+	if end < start {
+		end = start
+	}
+	return start <= last && end >= first
+}
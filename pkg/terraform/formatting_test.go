@@ -0,0 +1,185 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClient_FormatRangeFallsBackToDocument(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/formatting", func(params json.RawMessage) (interface{}, error) {
+		return []TextEdit{
+			{Range: Range{Start: Position{Line: 1}, End: Position{Line: 1, Character: 5}}, NewText: "  a = 1"},
+			{Range: Range{Start: Position{Line: 4}, End: Position{Line: 4, Character: 5}}, NewText: "  b = 2"},
+		}, nil
+	})
+
+	content := "locals {\n a=1\n}\nlocals {\n b=2\n}\n"
+	result, err := client.FormatRange(testContext(t), "file:///workspace/main.tf", content, Range{
+		Start: Position{Line: 3}, End: Position{Line: 5, Character: 1},
+	}, DefaultFormattingOptions())
+	if err != nil {
+		t.Fatalf("Failed to format range: %v", err)
+	}
+
+	if want := "locals {\n a=1\n}\nlocals {\n  b = 2\n}\n"; result.Formatted != want {
+		t.Errorf("Expected only the second block to be formatted, got: %q", result.Formatted)
+	}
+	if len(result.Edits) != 1 {
+		t.Errorf("Expected 1 edit, got: %+v", result.Edits)
+	}
+}
+
+func TestClient_FormatRangeSendsOptions(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`{"capabilities": {"documentRangeFormattingProvider": true}}`), nil
+	})
+	server.Handle("textDocument/rangeFormatting", func(params json.RawMessage) (interface{}, error) {
+		return []TextEdit{
+			{Range: Range{Start: Position{Line: 0, Character: 3}, End: Position{Line: 0, Character: 4}}, NewText: ""},
+		}, nil
+	})
+
+	ctx := testContext(t)
+	if err := client.Initialize(ctx, "/workspace"); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	opts := DefaultFormattingOptions()
+	opts.TrimTrailingWhitespace = true
+	result, err := client.FormatRange(ctx, "file:///workspace/main.tf", "a=1 \n", Range{
+		End: Position{Line: 0, Character: 4},
+	}, opts)
+	if err != nil {
+		t.Fatalf("Failed to format range: %v", err)
+	}
+	if result.Formatted != "a=1\n" {
+		t.Errorf("Expected %q, got: %q", "a=1\n", result.Formatted)
+	}
+
+	requests := server.Received("textDocument/rangeFormatting")
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 range formatting request, got: %d", len(requests))
+	}
+	var params DocumentRangeFormattingParams
+	if err := json.Unmarshal(requests[0].Params, &params); err != nil {
+		t.Fatalf("Failed to decode params: %v", err)
+	}
+	if !params.Options.TrimTrailingWhitespace || params.Options.TabSize != 2 || params.Range.End.Character != 4 {
+		t.Errorf("Unexpected params: %+v", params)
+	}
+}
+
+func TestClient_FormatOnType(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`{"capabilities": {"documentOnTypeFormattingProvider": {"firstTriggerCharacter": "}"}}}`), nil
+	})
+	server.Handle("textDocument/onTypeFormatting", func(params json.RawMessage) (interface{}, error) {
+		return []TextEdit{
+			{Range: Range{Start: Position{Line: 1}, End: Position{Line: 1, Character: 1}}, NewText: "  "},
+		}, nil
+	})
+	server.Handle("textDocument/formatting", func(params json.RawMessage) (interface{}, error) {
+		return []TextEdit{
+			{Range: Range{Start: Position{Line: 0}, End: Position{Line: 0, Character: 7}}, NewText: "locals {"},
+		}, nil
+	})
+
+	ctx := testContext(t)
+	if err := client.Initialize(ctx, "/workspace"); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	uri := "file:///workspace/main.tf"
+	content := "locals{\n\ta = 1\n}"
+	result, err := client.FormatOnType(ctx, uri, content, 2, 1, "}", DefaultFormattingOptions())
+	if err != nil {
+		t.Fatalf("Failed to format on type: %v", err)
+	}
+	if want := "locals{\n  a = 1\n}"; result.Formatted != want {
+		t.Errorf("Expected %q, got: %q", want, result.Formatted)
+	}
+
+	// Characters that are not triggers format the current line instead
+	result, err = client.FormatOnType(ctx, uri, content, 0, 7, "{", DefaultFormattingOptions())
+	if err != nil {
+		t.Fatalf("Failed to format on type: %v", err)
+	}
+	if want := "locals {\n\ta = 1\n}"; result.Formatted != want {
+		t.Errorf("Expected %q, got: %q", want, result.Formatted)
+	}
+	if got := len(server.Received("textDocument/onTypeFormatting")); got != 1 {
+		t.Errorf("Expected 1 on-type formatting request, got: %d", got)
+	}
+}
+
+func TestClient_FormatOnTypeLeavesNextLine(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/formatting", func(params json.RawMessage) (interface{}, error) {
+		return []TextEdit{
+			{Range: Range{Start: Position{Line: 0}, End: Position{Line: 0, Character: 7}}, NewText: "locals {"},
+			{Range: Range{Start: Position{Line: 1}, End: Position{Line: 1, Character: 7}}, NewText: "  a = 1"},
+		}, nil
+	})
+
+	// The line after the typed one is badly formatted and has trailing
+	// whitespace, but only the typed line may change
+	opts := DefaultFormattingOptions()
+	opts.TrimTrailingWhitespace = true
+	content := "locals{ \n\ta=1   \n}\n"
+	result, err := client.FormatOnType(testContext(t), "file:///workspace/main.tf", content, 0, 7, "{", opts)
+	if err != nil {
+		t.Fatalf("Failed to format on type: %v", err)
+	}
+	if want := "locals {\n\ta=1   \n}\n"; result.Formatted != want {
+		t.Errorf("Expected %q, got: %q", want, result.Formatted)
+	}
+}
+
+func TestClient_FormatAppliesWhitespaceOptions(t *testing.T) {
+	server, client := newTestClient(t)
+	// terraform fmt leaves trailing whitespace in comments and extra newlines
+	server.Handle("textDocument/formatting", func(params json.RawMessage) (interface{}, error) {
+		return []TextEdit{}, nil
+	})
+
+	content := "# note  \nlocals {}  \n# end \n\n\n"
+	opts := DefaultFormattingOptions()
+	opts.TrimTrailingWhitespace = true
+	opts.TrimFinalNewlines = true
+
+	result, err := client.FormatDocument(testContext(t), "file:///workspace/main.tf", content, opts)
+	if err != nil {
+		t.Fatalf("Failed to format document: %v", err)
+	}
+	if want := "# note\nlocals {}\n# end\n"; result.Formatted != want {
+		t.Errorf("Expected %q, got: %q", want, result.Formatted)
+	}
+	if formatted, err := ApplyTextEdits(content, result.Edits); err != nil || formatted != result.Formatted {
+		t.Errorf("Expected the edits to produce the result, got: %q (%v)", formatted, err)
+	}
+
+	// Only the lines of a range are trimmed, and the end of the file is
+	// left alone unless the range reaches it
+	result, err = client.FormatRange(testContext(t), "file:///workspace/main.tf", content, Range{
+		Start: Position{Line: 1}, End: Position{Line: 1, Character: 11},
+	}, opts)
+	if err != nil {
+		t.Fatalf("Failed to format range: %v", err)
+	}
+	if want := "# note  \nlocals {}\n# end \n\n\n"; result.Formatted != want {
+		t.Errorf("Expected %q, got: %q", want, result.Formatted)
+	}
+
+	opts = DefaultFormattingOptions()
+	opts.InsertFinalNewline = true
+	result, err = client.FormatDocument(testContext(t), "file:///workspace/main.tf", "locals {}", opts)
+	if err != nil {
+		t.Fatalf("Failed to format document: %v", err)
+	}
+	if result.Formatted != "locals {}\n" || len(result.Edits) != 1 {
+		t.Errorf("Expected a final newline to be inserted, got: %q with %+v", result.Formatted, result.Edits)
+	}
+}
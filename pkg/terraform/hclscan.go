@@ -0,0 +1,324 @@
+package terraform
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// blockLabelPattern matches the block type and labels in a block header
+var blockLabelPattern = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*"|[A-Za-z_][\w-]*`)
+
+// hclNode is a block or an attribute found by scanHCL. Offsets are byte
+// offsets into the scanned text; ends are exclusive.
+type hclNode struct {
+	block      bool
+	start, end int
+
+	// The value of an attribute
+	exprStart, exprEnd int
+
+	// The opening brace and the items in the body of a block
+	bodyStart int
+	children  []*hclNode
+}
+
+// scanHCL finds the blocks and attributes of a Terraform file. It only
+// tracks nesting, strings, heredocs and comments, which is enough to find
+// where items start and end without a full HCL parser. Expressions are not
+// looked into, so attributes of object values are not reported.
+func scanHCL(src string) []*hclNode {
+	s := hclScanner{src: src}
+	nodes, _ := s.body(0, false)
+	return nodes
+}
+
+// hclBlockHeader splits the header of a block into its type and labels.
+// Quoted labels are unquoted.
+func hclBlockHeader(src string, node *hclNode) (string, []string) {
+	fields := blockLabelPattern.FindAllString(src[node.start:node.bodyStart], -1)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	labels := make([]string, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		if unquoted, err := strconv.Unquote(field); err == nil {
+			field = unquoted
+		}
+		labels = append(labels, field)
+	}
+	return fields[0], labels
+}
+
+// hclAttribute returns the value of the attribute with the given name in
+// the body of a block, and whether it was found
+func hclAttribute(src string, node *hclNode, name string) (string, bool) {
+	s := hclScanner{src: src}
+	for _, child := range node.children {
+		if !child.block && src[child.start:s.identEnd(child.start)] == name {
+			return src[child.exprStart:child.exprEnd], true
+		}
+	}
+	return "", false
+}
+
+// hclPath returns the nodes containing offset, innermost first
+func hclPath(nodes []*hclNode, offset int) []*hclNode {
+	var path []*hclNode
+	for {
+		var inner *hclNode
+		for _, node := range nodes {
+			if node.start <= offset && offset <= node.end {
+				inner = node
+				break
+			}
+		}
+		if inner == nil {
+			break
+		}
+		path = append([]*hclNode{inner}, path...)
+		nodes = inner.children
+	}
+	return path
+}
+
+type hclScanner struct {
+	src string
+}
+
+// body scans the items of a body starting at i. In a block body it stops at
+// the closing brace and returns its offset.
+func (s *hclScanner) body(i int, inBlock bool) ([]*hclNode, int) {
+	var nodes []*hclNode
+
+	for {
+		i = s.skipSpace(i)
+		if i >= len(s.src) {
+			return nodes, i
+		}
+		if s.src[i] == '}' {
+			if inBlock {
+				return nodes, i
+			}
+			i++
+			continue
+		}
+
+		start := i
+
+		// An attribute is an identifier followed by a single '='
+		if isIdentStart(s.src[i]) {
+			name := s.identEnd(i)
+			eq := name
+			for eq < len(s.src) && (s.src[eq] == ' ' || s.src[eq] == '\t') {
+				eq++
+			}
+			if eq < len(s.src) && s.src[eq] == '=' && (eq+1 == len(s.src) || s.src[eq+1] != '=') {
+				exprStart := eq + 1
+				for exprStart < len(s.src) && (s.src[exprStart] == ' ' || s.src[exprStart] == '\t') {
+					exprStart++
+				}
+				exprEnd := s.expressionEnd(exprStart)
+				if exprEnd < exprStart {
+					exprEnd = exprStart
+				}
+				nodes = append(nodes, &hclNode{start: start, end: exprEnd, exprStart: exprStart, exprEnd: exprEnd})
+				i = exprEnd
+				continue
+			}
+		}
+
+		// Anything else on the line up to an opening brace is a block header
+		j := i
+		for j < len(s.src) && s.src[j] != '{' && s.src[j] != '}' && s.src[j] != '\n' {
+			if s.src[j] == '"' {
+				j = s.stringEnd(j)
+			} else {
+				j++
+			}
+		}
+		if j < len(s.src) && s.src[j] == '{' {
+			children, closing := s.body(j+1, true)
+			end := closing
+			if closing < len(s.src) {
+				end++
+			}
+			nodes = append(nodes, &hclNode{block: true, start: start, end: end, bodyStart: j, children: children})
+			i = end
+			continue
+		}
+
+		// Skip whatever could not be recognised
+		if next := s.expressionEnd(i); next > i {
+			i = next
+		} else {
+			i++
+		}
+	}
+}
+
+// expressionEnd returns the end of the expression starting at i: the first
+// newline, comment or closing bracket outside of any nesting, with trailing
+// whitespace removed
+func (s *hclScanner) expressionEnd(i int) int {
+	depth := 0
+	end := len(s.src)
+
+scan:
+	for i < len(s.src) {
+		switch c := s.src[i]; {
+		case c == '"':
+			i = s.stringEnd(i)
+			continue
+		case strings.HasPrefix(s.src[i:], "<<"):
+			if next := s.heredocEnd(i); next > i {
+				i = next
+				continue
+			}
+		case c == '#' || strings.HasPrefix(s.src[i:], "//"):
+			if depth == 0 {
+				end = i
+				break scan
+			}
+			i = s.lineEnd(i)
+			continue
+		case strings.HasPrefix(s.src[i:], "/*"):
+			i = s.commentEnd(i)
+			continue
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			if depth == 0 {
+				end = i
+				break scan
+			}
+			depth--
+		case c == '\n':
+			if depth == 0 {
+				end = i
+				break scan
+			}
+		}
+		i++
+	}
+
+	for end > 0 && strings.ContainsRune(" \t\r", rune(s.src[end-1])) {
+		end--
+	}
+	return end
+}
+
+// skipSpace skips whitespace, newlines and comments
+func (s *hclScanner) skipSpace(i int) int {
+	for i < len(s.src) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(s.src[i])):
+			i++
+		case s.src[i] == '#' || strings.HasPrefix(s.src[i:], "//"):
+			i = s.lineEnd(i)
+		case strings.HasPrefix(s.src[i:], "/*"):
+			i = s.commentEnd(i)
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// stringEnd returns the offset after the quoted string starting at i,
+// including any template interpolations in it. An unterminated string ends
+// at the end of the line.
+func (s *hclScanner) stringEnd(i int) int {
+	for j := i + 1; j < len(s.src); {
+		switch c := s.src[j]; {
+		case c == '\\':
+			j += 2
+		case c == '"':
+			return j + 1
+		case c == '\n':
+			return j
+		case (c == '$' || c == '%') && j+1 < len(s.src) && s.src[j+1] == '{':
+			j = s.templateEnd(j + 2)
+		default:
+			j++
+		}
+	}
+	return len(s.src)
+}
+
+// templateEnd returns the offset after the closing brace of a template
+// interpolation or directive whose content starts at i
+func (s *hclScanner) templateEnd(i int) int {
+	depth := 1
+	for i < len(s.src) {
+		switch s.src[i] {
+		case '"':
+			i = s.stringEnd(i)
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return i
+}
+
+// heredocEnd returns the offset after the closing marker of the heredoc
+// starting at i, or i if there is no heredoc there
+func (s *hclScanner) heredocEnd(i int) int {
+	j := i + 2
+	if j < len(s.src) && s.src[j] == '-' {
+		j++
+	}
+	if j >= len(s.src) || !isIdentStart(s.src[j]) {
+		return i
+	}
+	marker := s.src[j:s.identEnd(j)]
+
+	for line := s.lineEnd(j); line < len(s.src); {
+		start := line + 1
+		line = s.lineEnd(start)
+		text := strings.TrimRight(s.src[start:line], "\r")
+		if strings.TrimSpace(text) == marker {
+			return start + strings.Index(text, marker) + len(marker)
+		}
+	}
+	return len(s.src)
+}
+
+// lineEnd returns the offset of the newline ending the line containing i
+func (s *hclScanner) lineEnd(i int) int {
+	if n := strings.IndexByte(s.src[i:], '\n'); n >= 0 {
+		return i + n
+	}
+	return len(s.src)
+}
+
+// commentEnd returns the offset after the block comment starting at i
+func (s *hclScanner) commentEnd(i int) int {
+	if n := strings.Index(s.src[i+2:], "*/"); n >= 0 {
+		return i + 2 + n + 2
+	}
+	return len(s.src)
+}
+
+func (s *hclScanner) identEnd(i int) int {
+	for i < len(s.src) && isIdentChar(s.src[i]) {
+		i++
+	}
+	return i
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
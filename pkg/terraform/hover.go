@@ -0,0 +1,114 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Hover returns the documentation for the attribute, block, resource type or
+// function at a position, rendered as markdown
+func (c *Client) Hover(ctx context.Context, uri, content string, line, character int) (*HoverResult, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hover: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("hover error: %s", resp.Error.Message)
+	}
+
+	var hover *struct {
+		Contents json.RawMessage `json:"contents"`
+		Range    *Range          `json:"range,omitempty"`
+	}
+	if err := resp.UnmarshalResult(&hover); err != nil {
+		return nil, fmt.Errorf("failed to decode hover: %w", err)
+	}
+
+	result := &HoverResult{
+		URI: uri,
+	}
+	if hover == nil {
+		return result, nil
+	}
+
+	contents, err := hoverMarkdown(hover.Contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode hover contents: %w", err)
+	}
+	result.Contents = contents
+	result.Range = hover.Range
+
+	return result, nil
+}
+
+// hoverMarkdown converts hover contents, which may be MarkupContent, a
+// MarkedString or an array of MarkedStrings, to markdown
+func hoverMarkdown(data json.RawMessage) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return "", nil
+	}
+
+	if data[0] == '[' {
+		var parts []json.RawMessage
+		if err := json.Unmarshal(data, &parts); err != nil {
+			return "", err
+		}
+
+		sections := make([]string, 0, len(parts))
+		for _, part := range parts {
+			section, err := hoverMarkdown(part)
+			if err != nil {
+				return "", err
+			}
+			if section != "" {
+				sections = append(sections, section)
+			}
+		}
+		return strings.Join(sections, "\n\n"), nil
+	}
+
+	if data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return "", err
+		}
+		return text, nil
+	}
+
+	var value struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+
+	switch {
+	case value.Kind != "":
+		// MarkupContent; plaintext is valid markdown apart from escaping,
+		// which does not matter for display to an agent
+		return value.Value, nil
+	case value.Language != "":
+		return fmt.Sprintf("```%s\n%s\n```", value.Language, value.Value), nil
+	default:
+		return value.Value, nil
+	}
+}
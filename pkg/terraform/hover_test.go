@@ -0,0 +1,73 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHoverMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{"null", `null`, ""},
+		{"markup content", `{"kind": "markdown", "value": "**ami** _string_"}`, "**ami** _string_"},
+		{"marked string", `"plain text"`, "plain text"},
+		{"code block", `{"language": "hcl", "value": "ami = \"\""}`, "```hcl\nami = \"\"\n```"},
+		{"array", `["first", {"language": "hcl", "value": "x"}, ""]`, "first\n\n```hcl\nx\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hoverMarkdown(json.RawMessage(tt.contents))
+			if err != nil {
+				t.Fatalf("Failed to decode hover contents: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_Hover(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/hover", func(params json.RawMessage) (interface{}, error) {
+		var p TextDocumentPositionParams
+		json.Unmarshal(params, &p)
+
+		if p.Position.Line != 1 {
+			return nil, nil
+		}
+		return json.RawMessage(`{
+			"contents": {"kind": "markdown", "value": "**instance_type** _string_\n\nInstance type to use"},
+			"range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 15}}
+		}`), nil
+	})
+
+	uri := "file:///workspace/main.tf"
+	content := "resource \"aws_instance\" \"x\" {\n  instance_type = \"t3.micro\"\n}\n"
+
+	result, err := client.Hover(testContext(t), uri, content, 1, 4)
+	if err != nil {
+		t.Fatalf("Failed to get hover: %v", err)
+	}
+	if result.Contents != "**instance_type** _string_\n\nInstance type to use" {
+		t.Errorf("Unexpected contents: %q", result.Contents)
+	}
+	if result.Range == nil || result.Range.End.Character != 15 {
+		t.Errorf("Expected hover range, got: %+v", result.Range)
+	}
+
+	result, err = client.Hover(testContext(t), uri, content, 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to get hover: %v", err)
+	}
+	if result.Contents != "" || result.Range != nil {
+		t.Errorf("Expected empty hover, got: %+v", result)
+	}
+	if got := len(server.Received("textDocument/didOpen")); got != 1 {
+		t.Errorf("Expected document to be opened once, got: %d", got)
+	}
+}
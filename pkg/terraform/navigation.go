@@ -0,0 +1,230 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// snippetContextLines is the number of lines shown before and after a
+	// location in its snippet
+	snippetContextLines = 2
+
+	// snippetMaxLines bounds the number of lines of the range itself shown in
+	// a snippet, since a definition may span a whole block
+	snippetMaxLines = 10
+)
+
+// Definition returns the locations where the symbol at a position is
+// defined, such as the variable block for var.x or the module block for
+// module.z
+func (c *Client) Definition(ctx context.Context, uri, content string, line, character int) ([]LocationResult, error) {
+	return c.locationRequest(ctx, "textDocument/definition", "definition", uri, content, TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+	})
+}
+
+// Declaration returns the locations where the symbol at a position is
+// declared
+func (c *Client) Declaration(ctx context.Context, uri, content string, line, character int) ([]LocationResult, error) {
+	return c.locationRequest(ctx, "textDocument/declaration", "declaration", uri, content, TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+	})
+}
+
+// References returns the locations that refer to the symbol at a position,
+// across the files of the workspace
+func (c *Client) References(ctx context.Context, uri, content string, line, character int, includeDeclaration bool) ([]LocationResult, error) {
+	return c.locationRequest(ctx, "textDocument/references", "references", uri, content, ReferenceParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+		Context: ReferenceContext{
+			IncludeDeclaration: includeDeclaration,
+		},
+	})
+}
+
+// locationRequest sends a request whose result is a list of locations and
+// attaches a snippet of the source to each of them
+func (c *Client) locationRequest(ctx context.Context, method, name, uri, content string, params interface{}) ([]LocationResult, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%s error: %s", name, resp.Error.Message)
+	}
+
+	var raw json.RawMessage
+	if err := resp.UnmarshalResult(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read %s result: %w", name, err)
+	}
+
+	locations, err := decodeLocations(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+
+	sources := make(map[string]string)
+	results := make([]LocationResult, len(locations))
+	for i, loc := range locations {
+		source, ok := sources[loc.URI]
+		if !ok {
+			// A missing file only leaves the snippet empty
+			source, _ = c.readSource(loc.URI)
+			sources[loc.URI] = source
+		}
+
+		results[i] = LocationResult{
+			URI:     loc.URI,
+			Path:    URIToPath(loc.URI),
+			Range:   loc.Range,
+			Snippet: sourceSnippet(source, loc.Range),
+		}
+	}
+
+	return results, nil
+}
+
+// readSource returns the content of a document, preferring the version
+// synced to the server over the file on disk
+func (c *Client) readSource(uri string) (string, error) {
+	if content, ok := c.documentContent(uri); ok {
+		return content, nil
+	}
+
+	data, err := os.ReadFile(URIToPath(uri))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decodeLocations decodes a result that is null, a Location, a LocationLink
+// or an array of either. Links are reduced to their target selection range.
+// Locations are sorted by URI and position.
+func decodeLocations(data json.RawMessage) ([]Location, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var parts []json.RawMessage
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &parts); err != nil {
+			return nil, err
+		}
+	} else {
+		parts = []json.RawMessage{data}
+	}
+
+	locations := make([]Location, 0, len(parts))
+	for _, part := range parts {
+		var value struct {
+			Location
+			LocationLink
+		}
+		if err := json.Unmarshal(part, &value); err != nil {
+			return nil, err
+		}
+
+		if value.TargetURI != "" {
+			locations = append(locations, Location{URI: value.TargetURI, Range: value.TargetSelectionRange})
+		} else {
+			locations = append(locations, value.Location)
+		}
+	}
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.URI != b.URI {
+			return a.URI < b.URI
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		return a.Range.Start.Character < b.Range.Start.Character
+	})
+
+	return locations, nil
+}
+
+// sourceSnippet returns the lines of content covered by r with a few lines
+// of context, each prefixed with its 1-based line number. Lines inside the
+// range are marked with '>'.
+func sourceSnippet(content string, r Range) string {
+	if content == "" {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if r.Start.Line >= len(lines) {
+		return ""
+	}
+
+	endLine := r.End.Line
+	if endLine > r.Start.Line && r.End.Character == 0 {
+		// The range ends at the start of the next line
+		endLine--
+	}
+	truncated := false
+	if endLine-r.Start.Line >= snippetMaxLines {
+		endLine = r.Start.Line + snippetMaxLines - 1
+		truncated = true
+	}
+
+	first := r.Start.Line - snippetContextLines
+	if first < 0 {
+		first = 0
+	}
+	last := endLine + snippetContextLines
+	if truncated {
+		last = endLine
+	}
+	if last >= len(lines) {
+		last = len(lines) - 1
+	}
+
+	width := len(fmt.Sprint(last + 1))
+
+	var b strings.Builder
+	for i := first; i <= last; i++ {
+		marker := " "
+		if i >= r.Start.Line && i <= endLine {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s\n", marker, width, i+1, strings.TrimRight(lines[i], "\r"))
+	}
+	if truncated {
+		fmt.Fprintf(&b, "  %*s | ...\n", width, "")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLocations(t *testing.T) {
+	locations, err := decodeLocations(json.RawMessage(`[
+		{"uri": "file:///workspace/variables.tf", "range": {"start": {"line": 4, "character": 0}, "end": {"line": 4, "character": 5}}},
+		{"targetUri": "file:///workspace/main.tf",
+		 "targetRange": {"start": {"line": 0, "character": 0}, "end": {"line": 3, "character": 1}},
+		 "targetSelectionRange": {"start": {"line": 0, "character": 9}, "end": {"line": 0, "character": 14}}},
+		{"uri": "file:///workspace/variables.tf", "range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 5}}}
+	]`))
+	if err != nil {
+		t.Fatalf("Failed to decode locations: %v", err)
+	}
+
+	if len(locations) != 3 {
+		t.Fatalf("Expected 3 locations, got: %d", len(locations))
+	}
+	if locations[0].URI != "file:///workspace/main.tf" || locations[0].Range.Start.Character != 9 {
+		t.Errorf("Expected link target selection range first, got: %+v", locations[0])
+	}
+	if locations[1].Range.Start.Line != 1 || locations[2].Range.Start.Line != 4 {
+		t.Errorf("Expected locations sorted by position, got: %+v", locations)
+	}
+
+	single, err := decodeLocations(json.RawMessage(`{"uri": "file:///workspace/a.tf", "range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 1}}}`))
+	if err != nil || len(single) != 1 {
+		t.Errorf("Expected a single location, got: %+v, %v", single, err)
+	}
+
+	none, err := decodeLocations(json.RawMessage(`null`))
+	if err != nil || len(none) != 0 {
+		t.Errorf("Expected no locations, got: %+v, %v", none, err)
+	}
+}
+
+func TestSourceSnippet(t *testing.T) {
+	content := "a\nb\nc\nd\ne\nf\n"
+
+	got := sourceSnippet(content, Range{Start: Position{Line: 2, Character: 0}, End: Position{Line: 2, Character: 1}})
+	want := "  1 | a\n  2 | b\n> 3 | c\n  4 | d\n  5 | e\n"
+	if got != want {
+		t.Errorf("Expected snippet:\n%s\ngot:\n%s", want, got)
+	}
+
+	long := strings.Repeat("x\n", 30)
+	got = sourceSnippet(long, Range{Start: Position{Line: 0}, End: Position{Line: 20}})
+	if !strings.HasSuffix(got, "   | ...\n") {
+		t.Errorf("Expected truncated snippet, got:\n%s", got)
+	}
+	if n := strings.Count("\n"+got, "\n>"); n != snippetMaxLines {
+		t.Errorf("Expected %d marked lines, got: %d", snippetMaxLines, n)
+	}
+
+	if got := sourceSnippet("", Range{}); got != "" {
+		t.Errorf("Expected empty snippet for unreadable source, got: %q", got)
+	}
+}
+
+func TestURIToPath(t *testing.T) {
+	if got := URIToPath("file:///my%20workspace/main.tf"); got != filepath.FromSlash("/my workspace/main.tf") {
+		t.Errorf("Expected decoded path, got: %s", got)
+	}
+	if got := URIToPath("untitled:1"); got != "untitled:1" {
+		t.Errorf("Expected non-file URI unchanged, got: %s", got)
+	}
+}
+
+func TestPathToURI(t *testing.T) {
+	uri := PathToURI("/my workspace/modules/#1/main.tf")
+	if uri != "file:///my%20workspace/modules/%231/main.tf" {
+		t.Errorf("Expected encoded URI, got: %s", uri)
+	}
+	if got := URIToPath(uri); got != filepath.FromSlash("/my workspace/modules/#1/main.tf") {
+		t.Errorf("Expected the path back, got: %s", got)
+	}
+}
+
+func TestClient_DefinitionAndReferences(t *testing.T) {
+	server, client := newTestClient(t)
+
+	dir := t.TempDir()
+	variablesPath := filepath.Join(dir, "variables.tf")
+	if err := os.WriteFile(variablesPath, []byte("variable \"region\" {\n  type = string\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	variablesURI := "file://" + variablesPath
+	mainURI := "file://" + filepath.Join(dir, "main.tf")
+	mainContent := "provider \"aws\" {\n  region = var.region\n}\n"
+
+	server.Handle("textDocument/definition", func(params json.RawMessage) (interface{}, error) {
+		return []LocationLink{{
+			TargetURI:            variablesURI,
+			TargetRange:          Range{Start: Position{Line: 0}, End: Position{Line: 2, Character: 1}},
+			TargetSelectionRange: Range{Start: Position{Line: 0, Character: 9}, End: Position{Line: 0, Character: 17}},
+		}}, nil
+	})
+
+	var refParams ReferenceParams
+	server.Handle("textDocument/references", func(params json.RawMessage) (interface{}, error) {
+		json.Unmarshal(params, &refParams)
+		return []Location{
+			{URI: mainURI, Range: Range{Start: Position{Line: 1, Character: 11}, End: Position{Line: 1, Character: 21}}},
+		}, nil
+	})
+
+	definitions, err := client.Definition(testContext(t), mainURI, mainContent, 1, 15)
+	if err != nil {
+		t.Fatalf("Failed to get definition: %v", err)
+	}
+	if len(definitions) != 1 {
+		t.Fatalf("Expected 1 definition, got: %d", len(definitions))
+	}
+	if definitions[0].Path != variablesPath {
+		t.Errorf("Expected path %s, got: %s", variablesPath, definitions[0].Path)
+	}
+	if !strings.Contains(definitions[0].Snippet, "> 1 | variable \"region\" {") {
+		t.Errorf("Expected snippet read from disk, got:\n%s", definitions[0].Snippet)
+	}
+
+	references, err := client.References(testContext(t), mainURI, mainContent, 0, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to get references: %v", err)
+	}
+	if refParams.Context.IncludeDeclaration {
+		t.Error("Expected includeDeclaration to be false")
+	}
+	if len(references) != 1 || !strings.Contains(references[0].Snippet, "> 2 |   region = var.region") {
+		t.Errorf("Expected snippet from the open document, got: %+v", references)
+	}
+}
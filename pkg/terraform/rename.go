@@ -0,0 +1,113 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp"
+)
+
+// PrepareRename checks that the symbol at a position can be renamed and
+// returns its range. It returns nil if there is nothing to rename there.
+// Servers without prepareRename support get DefaultBehavior.
+func (c *Client) PrepareRename(ctx context.Context, uri, content string, line, character int) (*PrepareRenameResult, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/prepareRename", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare rename: %w", err)
+	}
+
+	if resp.Error != nil {
+		if resp.Error.Code == lsp.CodeMethodNotFound {
+			return &PrepareRenameResult{DefaultBehavior: true}, nil
+		}
+		return nil, fmt.Errorf("prepare rename error: %s", resp.Error.Message)
+	}
+
+	var raw json.RawMessage
+	if err := resp.UnmarshalResult(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read prepare rename result: %w", err)
+	}
+
+	result, err := decodePrepareRename(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode prepare rename result: %w", err)
+	}
+
+	return result, nil
+}
+
+// Rename asks the server for the edits that rename the symbol at a position
+// across the workspace. The other open documents are synced from disk
+// first, so that the edits to them match the files. Use
+// ResolveWorkspaceEdit to preview the result and ApplyFileChanges to write
+// it.
+func (c *Client) Rename(ctx context.Context, uri, content string, line, character int, newName string) (*WorkspaceEdit, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	if err := c.RefreshDocuments(ctx, uri); err != nil {
+		return nil, fmt.Errorf("failed to sync documents: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/rename", RenameParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+		NewName: newName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("rename error: %s", resp.Error.Message)
+	}
+
+	edit := &WorkspaceEdit{}
+	if err := resp.UnmarshalResult(edit); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace edit: %w", err)
+	}
+
+	return edit, nil
+}
+
+// decodePrepareRename decodes a prepareRename result, which is null, a
+// Range, {range, placeholder} or {defaultBehavior}
+func decodePrepareRename(data json.RawMessage) (*PrepareRenameResult, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var value struct {
+		Range
+		PrepareRenameResult
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	result := value.PrepareRenameResult
+	if !result.DefaultBehavior && result.Range == (Range{}) {
+		result.Range = value.Range
+	}
+	return &result, nil
+}
@@ -0,0 +1,220 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodePrepareRename(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   *PrepareRenameResult
+	}{
+		{"null", `null`, nil},
+		{
+			"range",
+			`{"start": {"line": 1, "character": 4}, "end": {"line": 1, "character": 10}}`,
+			&PrepareRenameResult{Range: Range{Start: Position{Line: 1, Character: 4}, End: Position{Line: 1, Character: 10}}},
+		},
+		{
+			"range with placeholder",
+			`{"range": {"start": {"line": 2, "character": 0}, "end": {"line": 2, "character": 3}}, "placeholder": "foo"}`,
+			&PrepareRenameResult{Range: Range{Start: Position{Line: 2}, End: Position{Line: 2, Character: 3}}, Placeholder: "foo"},
+		},
+		{"default behavior", `{"defaultBehavior": true}`, &PrepareRenameResult{DefaultBehavior: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePrepareRename(json.RawMessage(tt.result))
+			if err != nil {
+				t.Fatalf("Failed to decode prepare rename result: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("Expected %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWorkspaceEdit_RejectsResourceOperations(t *testing.T) {
+	var edit WorkspaceEdit
+	err := json.Unmarshal([]byte(`{"documentChanges": [{"kind": "create", "uri": "file:///workspace/new.tf"}]}`), &edit)
+	if err == nil {
+		t.Error("Expected an error for a create file operation")
+	}
+}
+
+func TestClient_RenameAndApply(t *testing.T) {
+	server, client := newTestClient(t)
+
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.tf")
+	variablesPath := filepath.Join(dir, "variables.tf")
+	mainContent := "provider \"aws\" {\n  region = var.region\n}\n"
+	if err := os.WriteFile(mainPath, []byte(mainContent), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(variablesPath, []byte("variable \"region\" {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	mainURI := "file://" + mainPath
+	variablesURI := "file://" + variablesPath
+
+	version := 1
+	server.Handle("textDocument/rename", func(params json.RawMessage) (interface{}, error) {
+		var p RenameParams
+		json.Unmarshal(params, &p)
+
+		return WorkspaceEdit{
+			Changes: map[string][]TextEdit{
+				variablesURI: {{Range: Range{Start: Position{Line: 0, Character: 10}, End: Position{Line: 0, Character: 16}}, NewText: p.NewName}},
+			},
+			DocumentChanges: []TextDocumentEdit{{
+				TextDocument: OptionalVersionedTextDocumentIdentifier{URI: mainURI, Version: &version},
+				Edits:        []TextEdit{{Range: Range{Start: Position{Line: 1, Character: 15}, End: Position{Line: 1, Character: 21}}, NewText: p.NewName}},
+			}},
+		}, nil
+	})
+
+	edit, err := client.Rename(testContext(t), mainURI, mainContent, 1, 17, "aws_region")
+	if err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+
+	changes, err := client.ResolveWorkspaceEdit(*edit)
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace edit: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Path != mainPath {
+		t.Fatalf("Expected changes to both files sorted by path, got: %+v", changes)
+	}
+
+	// Nothing is written until the changes are applied
+	if data, _ := os.ReadFile(variablesPath); string(data) != "variable \"region\" {}\n" {
+		t.Errorf("Expected file to be unchanged before apply, got: %q", data)
+	}
+
+	if err := client.ApplyFileChanges(testContext(t), changes); err != nil {
+		t.Fatalf("Failed to apply changes: %v", err)
+	}
+
+	if data, _ := os.ReadFile(mainPath); string(data) != "provider \"aws\" {\n  region = var.aws_region\n}\n" {
+		t.Errorf("Unexpected main.tf content: %q", data)
+	}
+	if data, _ := os.ReadFile(variablesPath); string(data) != "variable \"aws_region\" {}\n" {
+		t.Errorf("Unexpected variables.tf content: %q", data)
+	}
+	if info, _ := os.Stat(mainPath); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions to be kept, got: %v", info.Mode().Perm())
+	}
+	if got := client.DocumentVersion(mainURI); got != 2 {
+		t.Errorf("Expected the open document to be synced to version 2, got: %d", got)
+	}
+
+	// The edit was computed against version 1
+	if _, err := client.ResolveWorkspaceEdit(*edit); !errors.Is(err, ErrStaleEdit) {
+		t.Errorf("Expected ErrStaleEdit, got: %v", err)
+	}
+}
+
+func TestClient_ApplyFileChangesSyncsHeldDocument(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := testContext(t)
+
+	path := filepath.Join(t.TempDir(), "main.tf")
+	if err := os.WriteFile(path, []byte("a\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	uri := PathToURI(path)
+
+	unlock, err := client.LockDocument(ctx, uri)
+	if err != nil {
+		t.Fatalf("Failed to lock document: %v", err)
+	}
+	defer unlock()
+	client.openDocument(ctx, uri, "a\n")
+
+	changes := []FileChange{{URI: uri, Path: path, Original: "a\n", Content: "b\n", Edits: 1}}
+	if err := client.ApplyFileChanges(ctx, changes, uri); err != nil {
+		t.Fatalf("Failed to apply changes: %v", err)
+	}
+
+	roundTrip(t, server, client)
+	didChanges := server.Received("textDocument/didChange")
+	if len(didChanges) != 1 {
+		t.Fatalf("Expected the held document to be synced with didChange, got: %d", len(didChanges))
+	}
+	var params DidChangeTextDocumentParams
+	json.Unmarshal(didChanges[0].Params, &params)
+	if params.TextDocument.Version != 2 || params.ContentChanges[0].Text != "b\n" {
+		t.Errorf("Expected version 2 with the applied content, got: %+v", params)
+	}
+	if content, _ := client.documentContent(uri); content != "b\n" {
+		t.Errorf("Expected the synced content to be the applied one, got: %q", content)
+	}
+}
+
+func TestClient_RenameRefreshesDocumentsAndRejectsStaleFiles(t *testing.T) {
+	server, client := newTestClient(t)
+
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.tf")
+	variablesPath := filepath.Join(dir, "variables.tf")
+	mainContent := "provider \"aws\" {\n  region = var.region\n}\n"
+	if err := os.WriteFile(mainPath, []byte(mainContent), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(variablesPath, []byte("variable \"region\" {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	mainURI := PathToURI(mainPath)
+	variablesURI := PathToURI(variablesPath)
+
+	// variables.tf was opened by an earlier call and has changed on disk since
+	if _, err := client.openDocument(testContext(t), variablesURI, "# old\n"); err != nil {
+		t.Fatalf("Failed to open document: %v", err)
+	}
+
+	server.Handle("textDocument/rename", func(params json.RawMessage) (interface{}, error) {
+		return WorkspaceEdit{
+			Changes: map[string][]TextEdit{
+				mainURI:      {{Range: Range{Start: Position{Line: 1, Character: 15}, End: Position{Line: 1, Character: 21}}, NewText: "aws_region"}},
+				variablesURI: {{Range: Range{Start: Position{Line: 0, Character: 10}, End: Position{Line: 0, Character: 16}}, NewText: "aws_region"}},
+			},
+		}, nil
+	})
+
+	edit, err := client.Rename(testContext(t), mainURI, mainContent, 1, 17, "aws_region")
+	if err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	if content, _ := client.documentContent(variablesURI); content != "variable \"region\" {}\n" {
+		t.Errorf("Expected variables.tf to be synced from disk before the rename, got: %q", content)
+	}
+
+	changes, err := client.ResolveWorkspaceEdit(*edit)
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace edit: %v", err)
+	}
+
+	// variables.tf changes again before the edits are applied
+	if err := os.WriteFile(variablesPath, []byte("variable \"region\" {\n  default = \"us-east-1\"\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := client.ApplyFileChanges(testContext(t), changes); !errors.Is(err, ErrStaleFile) {
+		t.Fatalf("Expected ErrStaleFile, got: %v", err)
+	}
+	if data, _ := os.ReadFile(mainPath); string(data) != mainContent {
+		t.Errorf("Expected no file to be written, got main.tf: %q", data)
+	}
+	if data, _ := os.ReadFile(variablesPath); !strings.Contains(string(data), "us-east-1") {
+		t.Errorf("Expected the edit on disk to be kept, got: %q", data)
+	}
+}
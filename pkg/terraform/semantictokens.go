@@ -0,0 +1,187 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+)
+
+// semanticTokenTypes are the token types announced to the server: the
+// standard LSP types followed by the HCL specific types of terraform-ls.
+// terraform-ls leaves out tokens whose type the client did not announce.
+var semanticTokenTypes = []string{
+	"namespace", "type", "class", "enum", "interface", "struct", "typeParameter",
+	"parameter", "variable", "property", "enumMember", "event", "function",
+	"method", "macro", "keyword", "modifier", "comment", "string", "number",
+	"regexp", "operator",
+	"hcl-attrName", "hcl-blockType", "hcl-blockLabel", "hcl-bool", "hcl-string",
+	"hcl-number", "hcl-objectKey", "hcl-mapKey", "hcl-keyword", "hcl-referenceStep",
+	"hcl-typeComplex", "hcl-typePrimitive", "hcl-functionName",
+}
+
+// semanticTokenModifiers are the token modifiers announced to the server
+var semanticTokenModifiers = []string{
+	"declaration", "definition", "readonly", "static", "deprecated", "abstract",
+	"async", "modification", "documentation", "defaultLibrary",
+	"hcl-dependent",
+	"terraform-data", "terraform-locals", "terraform-module", "terraform-output",
+	"terraform-provider", "terraform-resource", "terraform-provisioner",
+	"terraform-connection", "terraform-variable", "terraform-terraform",
+	"terraform-backend", "terraform-name", "terraform-type",
+	"terraform-requiredProviders",
+}
+
+// SemanticTokens returns the classified tokens of a whole document
+func (c *Client) SemanticTokens(ctx context.Context, uri, content string) ([]SemanticToken, error) {
+	return c.semanticTokens(ctx, "textDocument/semanticTokens/full", uri, content, SemanticTokensParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+	})
+}
+
+// SemanticTokensRange returns the classified tokens that overlap a range of
+// a document. Servers that only support full documents are asked for all
+// tokens, which are then filtered.
+func (c *Client) SemanticTokensRange(ctx context.Context, uri, content string, r Range) ([]SemanticToken, error) {
+	if !c.semanticTokensRangeSupported() {
+		tokens, err := c.SemanticTokens(ctx, uri, content)
+		if err != nil {
+			return nil, err
+		}
+
+		filtered := tokens[:0]
+		for _, token := range tokens {
+			if rangesOverlap(r, token.Range()) {
+				filtered = append(filtered, token)
+			}
+		}
+		return filtered, nil
+	}
+
+	return c.semanticTokens(ctx, "textDocument/semanticTokens/range", uri, content, SemanticTokensRangeParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Range: r,
+	})
+}
+
+// Range returns the range a token covers
+func (t SemanticToken) Range() Range {
+	return Range{
+		Start: Position{Line: t.Line, Character: t.Character},
+		End:   Position{Line: t.Line, Character: t.Character + t.Length},
+	}
+}
+
+func (c *Client) semanticTokens(ctx context.Context, method, uri, content string, params interface{}) ([]SemanticToken, error) {
+	legend, ok := c.semanticTokensLegend()
+	if !ok {
+		return nil, fmt.Errorf("server does not provide semantic tokens")
+	}
+
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get semantic tokens: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("semantic tokens error: %s", resp.Error.Message)
+	}
+
+	var result *SemanticTokens
+	if err := resp.UnmarshalResult(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode semantic tokens: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	tokens, err := decodeSemanticTokens(result.Data, legend, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode semantic tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// semanticTokensLegend returns the legend announced by the server, and
+// whether the server provides semantic tokens at all
+func (c *Client) semanticTokensLegend() (SemanticTokensLegend, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capabilities.SemanticTokensProvider == nil {
+		return SemanticTokensLegend{}, false
+	}
+	return c.capabilities.SemanticTokensProvider.Legend, true
+}
+
+// semanticTokensRangeSupported reports whether the server accepts
+// textDocument/semanticTokens/range
+func (c *Client) semanticTokensRangeSupported() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	provider := c.capabilities.SemanticTokensProvider
+	return provider != nil && capabilityEnabled(provider.Range)
+}
+
+// decodeSemanticTokens converts relative token data to tokens with absolute
+// positions, resolving type and modifier indexes with the legend
+func decodeSemanticTokens(data []int, legend SemanticTokensLegend, content string) ([]SemanticToken, error) {
+	if len(data)%5 != 0 {
+		return nil, fmt.Errorf("token data length %d is not a multiple of 5", len(data))
+	}
+
+	idx := newLineIndex(content)
+	tokens := make([]SemanticToken, 0, len(data)/5)
+
+	line, character := 0, 0
+	for i := 0; i < len(data); i += 5 {
+		deltaLine, deltaStart, length, typeIndex, modifierSet := data[i], data[i+1], data[i+2], data[i+3], data[i+4]
+
+		if deltaLine > 0 {
+			line += deltaLine
+			character = deltaStart
+		} else {
+			character += deltaStart
+		}
+
+		if typeIndex < 0 || typeIndex >= len(legend.TokenTypes) {
+			return nil, fmt.Errorf("token type index %d out of range", typeIndex)
+		}
+
+		token := SemanticToken{
+			Line:      line,
+			Character: character,
+			Length:    length,
+			Type:      legend.TokenTypes[typeIndex],
+		}
+		for set := uint(modifierSet); set != 0; set &= set - 1 {
+			bit := bits.TrailingZeros(set)
+			if bit < len(legend.TokenModifiers) {
+				token.Modifiers = append(token.Modifiers, legend.TokenModifiers[bit])
+			}
+		}
+
+		start, err := idx.offset(token.Range().Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := idx.offset(token.Range().End)
+		if err != nil {
+			return nil, err
+		}
+		token.Text = content[start:end]
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,80 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeSemanticTokens(t *testing.T) {
+	legend := SemanticTokensLegend{
+		TokenTypes:     []string{"hcl-blockType", "hcl-blockLabel", "hcl-attrName"},
+		TokenModifiers: []string{"terraform-resource", "terraform-type", "terraform-name"},
+	}
+	content := "resource \"aws_instance\" \"web\" {\n  ami = \"é\"\n}\n"
+
+	tokens, err := decodeSemanticTokens([]int{
+		0, 0, 8, 0, 1, // resource
+		0, 9, 14, 1, 3, // "aws_instance"
+		0, 15, 5, 1, 5, // "web"
+		1, 2, 3, 2, 0, // ami
+	}, legend, content)
+	if err != nil {
+		t.Fatalf("Failed to decode tokens: %v", err)
+	}
+
+	want := []SemanticToken{
+		{Line: 0, Character: 0, Length: 8, Type: "hcl-blockType", Modifiers: []string{"terraform-resource"}, Text: "resource"},
+		{Line: 0, Character: 9, Length: 14, Type: "hcl-blockLabel", Modifiers: []string{"terraform-resource", "terraform-type"}, Text: "\"aws_instance\""},
+		{Line: 0, Character: 24, Length: 5, Type: "hcl-blockLabel", Modifiers: []string{"terraform-resource", "terraform-name"}, Text: "\"web\""},
+		{Line: 1, Character: 2, Length: 3, Type: "hcl-attrName", Text: "ami"},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("Expected %d tokens, got: %d", len(want), len(tokens))
+	}
+	for i := range want {
+		got, _ := json.Marshal(tokens[i])
+		expected, _ := json.Marshal(want[i])
+		if string(got) != string(expected) {
+			t.Errorf("Token %d: expected %s, got: %s", i, expected, got)
+		}
+	}
+
+	if _, err := decodeSemanticTokens([]int{0, 0, 1, 9, 0}, legend, content); err == nil {
+		t.Error("Expected an error for an unknown token type")
+	}
+	if _, err := decodeSemanticTokens([]int{0, 0, 1}, legend, content); err == nil {
+		t.Error("Expected an error for truncated token data")
+	}
+}
+
+func TestClient_SemanticTokensRangeFallsBackToFull(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`{"capabilities": {"semanticTokensProvider": {
+			"legend": {"tokenTypes": ["hcl-blockType", "hcl-attrName"], "tokenModifiers": []},
+			"full": true
+		}}}`), nil
+	})
+	server.Handle("textDocument/semanticTokens/full", func(params json.RawMessage) (interface{}, error) {
+		return SemanticTokens{Data: []int{0, 0, 6, 0, 0, 1, 2, 1, 1, 0}}, nil
+	})
+
+	ctx := testContext(t)
+	if err := client.Initialize(ctx, "/workspace"); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	content := "locals {\n  a = 1\n}\n"
+	tokens, err := client.SemanticTokensRange(ctx, "file:///workspace/main.tf", content, Range{
+		Start: Position{Line: 1}, End: Position{Line: 1, Character: 5},
+	})
+	if err != nil {
+		t.Fatalf("Failed to get semantic tokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].Text != "a" || tokens[0].Type != "hcl-attrName" {
+		t.Errorf("Expected only the attribute token in range, got: %+v", tokens)
+	}
+	if got := len(server.Received("textDocument/semanticTokens/range")); got != 0 {
+		t.Errorf("Expected no range request, got: %d", got)
+	}
+}
@@ -0,0 +1,327 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for SessionOptions
+const (
+	DefaultMaxSessions = 4
+	DefaultIdleTimeout = 10 * time.Minute
+
+	// initializeTimeout bounds how long terraform-ls may take to start and
+	// initialize
+	initializeTimeout = time.Minute
+
+	// shutdownTimeout bounds how long an evicted session may take to shut down
+	shutdownTimeout = 5 * time.Second
+)
+
+// ErrSessionManagerClosed is returned by Acquire after Close has been called
+var ErrSessionManagerClosed = errors.New("session manager closed")
+
+// SessionOptions configures a SessionManager
+type SessionOptions struct {
+	// MaxSessions is the number of terraform-ls processes kept running.
+	// When a new workspace is opened at the limit, the least recently used
+	// idle session is shut down. Sessions in use are never evicted, so the
+	// limit may be exceeded temporarily.
+	MaxSessions int
+
+	// IdleTimeout is how long an unused session is kept before it is shut down
+	IdleTimeout time.Duration
+
+	// NewClient starts a new terraform-ls client. It defaults to NewClient.
+	NewClient func() (*Client, error)
+}
+
+// session is one initialized terraform-ls client for a workspace root
+type session struct {
+	root     string
+	client   *Client
+	ready    chan struct{}
+	err      error
+	refs     int
+	lastUsed time.Time
+}
+
+// exited reports whether the session was started and its terraform-ls
+// process has exited since
+func (s *session) exited() bool {
+	select {
+	case <-s.ready:
+	default:
+		return false
+	}
+	if s.client == nil || s.client.lspClient == nil {
+		return false
+	}
+
+	select {
+	case <-s.client.lspClient.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// SessionManager keeps one initialized terraform-ls session per workspace
+// root. Sessions are started lazily on first use, reused across calls and
+// shut down when idle.
+type SessionManager struct {
+	opts SessionOptions
+
+	// ctx bounds the initialization of sessions, which outlives the call
+	// that started it. It is cancelled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	closed   bool
+	stop     chan struct{}
+
+	// shutdowns tracks evicted sessions shutting down in the background
+	shutdowns sync.WaitGroup
+}
+
+// NewSessionManager creates a session manager
+func NewSessionManager(opts SessionOptions) *SessionManager {
+	if opts.MaxSessions <= 0 {
+		opts.MaxSessions = DefaultMaxSessions
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+	if opts.NewClient == nil {
+		opts.NewClient = NewClient
+	}
+
+	m := &SessionManager{
+		opts:     opts,
+		sessions: make(map[string]*session),
+		stop:     make(chan struct{}),
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+
+	go m.reapIdle()
+
+	return m
+}
+
+// Acquire returns the initialized client for a workspace, starting and
+// initializing terraform-ls on first use, or again if it has exited. Initialization does not depend on
+// ctx, so a caller giving up does not fail others waiting for the same
+// workspace. The returned release function must be called once the caller
+// is done with the client.
+func (m *SessionManager) Acquire(ctx context.Context, workspaceRoot string) (*Client, func(), error) {
+	root, err := filepath.Abs(workspaceRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, nil, ErrSessionManagerClosed
+	}
+
+	s, exists := m.sessions[root]
+	var evicted []*session
+	if exists && s.exited() {
+		// terraform-ls died; start over instead of handing out a client
+		// whose every request fails
+		delete(m.sessions, root)
+		evicted = append(evicted, s)
+		exists = false
+	}
+	if !exists {
+		evicted = append(evicted, m.evictForNewLocked()...)
+		s = &session{
+			root:  root,
+			ready: make(chan struct{}),
+		}
+		m.sessions[root] = s
+	}
+	s.refs++
+	m.mu.Unlock()
+
+	// Evicted sessions shut down without holding up this call
+	if len(evicted) > 0 {
+		m.shutdowns.Add(1)
+		go func() {
+			defer m.shutdowns.Done()
+			shutdownSessions(evicted)
+		}()
+	}
+
+	if !exists {
+		go m.start(s)
+	}
+
+	select {
+	case <-s.ready:
+	case <-ctx.Done():
+		m.release(s)
+		return nil, nil, ctx.Err()
+	}
+
+	if s.err != nil {
+		m.release(s)
+		return nil, nil, s.err
+	}
+
+	var once sync.Once
+	return s.client, func() { once.Do(func() { m.release(s) }) }, nil
+}
+
+// Sessions returns the workspace roots that currently have a session
+func (m *SessionManager) Sessions() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	roots := make([]string, 0, len(m.sessions))
+	for root := range m.sessions {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// Close shuts down every session
+func (m *SessionManager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.stop)
+	m.cancel()
+
+	sessions := make([]*session, 0, len(m.sessions))
+	for root, s := range m.sessions {
+		sessions = append(sessions, s)
+		delete(m.sessions, root)
+	}
+	m.mu.Unlock()
+
+	shutdownSessions(sessions)
+	m.shutdowns.Wait()
+	return nil
+}
+
+// start launches and initializes terraform-ls for a new session. Failed
+// sessions are removed so that the next call retries.
+func (m *SessionManager) start(s *session) {
+	defer close(s.ready)
+
+	ctx, cancel := context.WithTimeout(m.ctx, initializeTimeout)
+	defer cancel()
+
+	client, err := m.opts.NewClient()
+	if err != nil {
+		s.err = err
+	} else if err := client.Initialize(ctx, s.root); err != nil {
+		client.Close()
+		s.err = fmt.Errorf("failed to initialize terraform-ls: %w", err)
+	} else {
+		s.client = client
+	}
+
+	if s.err != nil {
+		m.mu.Lock()
+		if m.sessions[s.root] == s {
+			delete(m.sessions, s.root)
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (m *SessionManager) release(s *session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.refs--
+	s.lastUsed = time.Now()
+}
+
+// evictForNewLocked removes least recently used idle sessions until there
+// is room for one more session
+func (m *SessionManager) evictForNewLocked() []*session {
+	var evicted []*session
+
+	for len(m.sessions) >= m.opts.MaxSessions {
+		var oldest *session
+		for _, s := range m.sessions {
+			if s.refs > 0 {
+				continue
+			}
+			if oldest == nil || s.lastUsed.Before(oldest.lastUsed) {
+				oldest = s
+			}
+		}
+		if oldest == nil {
+			break
+		}
+		delete(m.sessions, oldest.root)
+		evicted = append(evicted, oldest)
+	}
+
+	return evicted
+}
+
+// reapIdle periodically shuts down sessions unused for longer than the idle
+// timeout
+func (m *SessionManager) reapIdle() {
+	interval := m.opts.IdleTimeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			shutdownSessions(m.evictIdle(time.Now()))
+		}
+	}
+}
+
+// evictIdle removes the sessions that have been idle since before now minus
+// the idle timeout
+func (m *SessionManager) evictIdle(now time.Time) []*session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var evicted []*session
+	for root, s := range m.sessions {
+		if s.refs == 0 && now.Sub(s.lastUsed) > m.opts.IdleTimeout {
+			delete(m.sessions, root)
+			evicted = append(evicted, s)
+		}
+	}
+	return evicted
+}
+
+func shutdownSessions(sessions []*session) {
+	for _, s := range sessions {
+		<-s.ready
+		if s.client == nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		s.client.Shutdown(ctx)
+		cancel()
+	}
+}
@@ -0,0 +1,309 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp/lsptest"
+)
+
+// fakeSessions creates clients backed by fake servers and records them
+type fakeSessions struct {
+	t       *testing.T
+	mu      sync.Mutex
+	servers []*lsptest.Server
+	fail    bool
+}
+
+func (f *fakeSessions) newClient() (*Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fail {
+		return nil, errors.New("terraform-ls not found")
+	}
+
+	server := lsptest.NewServer()
+	f.t.Cleanup(func() { server.Close() })
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"capabilities": map[string]interface{}{}}, nil
+	})
+	server.Handle("shutdown", func(params json.RawMessage) (interface{}, error) {
+		return nil, nil
+	})
+
+	f.servers = append(f.servers, server)
+	return NewClientWithLSP(server.Client()), nil
+}
+
+func (f *fakeSessions) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.servers)
+}
+
+func TestSessionManager_ReusesSessions(t *testing.T) {
+	fake := &fakeSessions{t: t}
+	m := NewSessionManager(SessionOptions{NewClient: fake.newClient})
+	defer m.Close()
+
+	ctx := testContext(t)
+
+	first, release, err := m.Acquire(ctx, "/workspace/a")
+	if err != nil {
+		t.Fatalf("Failed to acquire session: %v", err)
+	}
+	release()
+
+	second, release, err := m.Acquire(ctx, "/workspace/a/../a")
+	if err != nil {
+		t.Fatalf("Failed to acquire session: %v", err)
+	}
+	release()
+
+	if first != second {
+		t.Error("Expected the same client for the same workspace")
+	}
+	if fake.count() != 1 {
+		t.Errorf("Expected 1 terraform-ls process, got: %d", fake.count())
+	}
+	if got := len(fake.servers[0].Received("initialize")); got != 1 {
+		t.Errorf("Expected 1 initialize request, got: %d", got)
+	}
+	if first.WorkspaceRoot() != "/workspace/a" {
+		t.Errorf("Expected workspace root /workspace/a, got: %s", first.WorkspaceRoot())
+	}
+}
+
+func TestSessionManager_EvictsLeastRecentlyUsed(t *testing.T) {
+	fake := &fakeSessions{t: t}
+	m := NewSessionManager(SessionOptions{MaxSessions: 2, NewClient: fake.newClient})
+	defer m.Close()
+
+	ctx := testContext(t)
+	for _, root := range []string{"/workspace/a", "/workspace/b"} {
+		_, release, err := m.Acquire(ctx, root)
+		if err != nil {
+			t.Fatalf("Failed to acquire session: %v", err)
+		}
+		release()
+		time.Sleep(time.Millisecond)
+	}
+
+	// b is in use and must survive even though a was used less recently
+	_, releaseB, err := m.Acquire(ctx, "/workspace/b")
+	if err != nil {
+		t.Fatalf("Failed to acquire session: %v", err)
+	}
+	_, releaseC, err := m.Acquire(ctx, "/workspace/c")
+	if err != nil {
+		t.Fatalf("Failed to acquire session: %v", err)
+	}
+	releaseB()
+	releaseC()
+	m.shutdowns.Wait()
+
+	want := []string{"/workspace/b", "/workspace/c"}
+	got := m.Sessions()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected sessions %v, got: %v", want, got)
+	}
+	if len(fake.servers[0].Received("shutdown")) != 1 {
+		t.Error("Expected the evicted session to be shut down")
+	}
+}
+
+func TestSessionManager_EvictsIdleSessions(t *testing.T) {
+	fake := &fakeSessions{t: t}
+	m := NewSessionManager(SessionOptions{IdleTimeout: time.Minute, NewClient: fake.newClient})
+	defer m.Close()
+
+	_, release, err := m.Acquire(testContext(t), "/workspace/a")
+	if err != nil {
+		t.Fatalf("Failed to acquire session: %v", err)
+	}
+
+	if evicted := m.evictIdle(time.Now().Add(2 * time.Minute)); len(evicted) != 0 {
+		t.Error("Expected sessions in use not to be evicted")
+	}
+
+	release()
+
+	if evicted := m.evictIdle(time.Now()); len(evicted) != 0 {
+		t.Error("Expected recently used session not to be evicted")
+	}
+	evicted := m.evictIdle(time.Now().Add(2 * time.Minute))
+	if len(evicted) != 1 {
+		t.Fatalf("Expected 1 idle session to be evicted, got: %d", len(evicted))
+	}
+	shutdownSessions(evicted)
+
+	if len(m.Sessions()) != 0 {
+		t.Errorf("Expected no sessions, got: %v", m.Sessions())
+	}
+}
+
+func TestSessionManager_RetriesFailedStart(t *testing.T) {
+	fake := &fakeSessions{t: t, fail: true}
+	m := NewSessionManager(SessionOptions{NewClient: fake.newClient})
+	defer m.Close()
+
+	ctx := testContext(t)
+	if _, _, err := m.Acquire(ctx, "/workspace/a"); err == nil {
+		t.Fatal("Expected an error when terraform-ls cannot start")
+	}
+
+	fake.mu.Lock()
+	fake.fail = false
+	fake.mu.Unlock()
+
+	_, release, err := m.Acquire(ctx, "/workspace/a")
+	if err != nil {
+		t.Fatalf("Expected the second attempt to succeed, got: %v", err)
+	}
+	release()
+}
+
+func TestSessionManager_InitializeOutlivesCaller(t *testing.T) {
+	fake := &fakeSessions{t: t}
+	started, release := make(chan struct{}), make(chan struct{})
+	m := NewSessionManager(SessionOptions{NewClient: func() (*Client, error) {
+		close(started)
+		<-release
+		return fake.newClient()
+	}})
+	defer m.Close()
+
+	// The first caller gives up while terraform-ls is starting
+	ctx, cancel := context.WithCancel(testContext(t))
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := m.Acquire(ctx, "/workspace/a")
+		errs <- err
+	}()
+	<-started
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, releaseClient, err := m.Acquire(testContext(t), "/workspace/a")
+		if err == nil {
+			releaseClient()
+		}
+		waiter <- err
+	}()
+
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the first caller to be cancelled, got: %v", err)
+	}
+
+	close(release)
+	if err := <-waiter; err != nil {
+		t.Errorf("Expected the other caller to get the session, got: %v", err)
+	}
+}
+
+func TestSessionManager_Close(t *testing.T) {
+	fake := &fakeSessions{t: t}
+	m := NewSessionManager(SessionOptions{NewClient: fake.newClient})
+
+	_, release, err := m.Acquire(testContext(t), "/workspace/a")
+	if err != nil {
+		t.Fatalf("Failed to acquire session: %v", err)
+	}
+	release()
+
+	m.Close()
+
+	if _, _, err := m.Acquire(context.Background(), "/workspace/a"); !errors.Is(err, ErrSessionManagerClosed) {
+		t.Errorf("Expected ErrSessionManagerClosed, got: %v", err)
+	}
+	if len(fake.servers[0].Received("shutdown")) != 1 {
+		t.Error("Expected the session to be shut down on close")
+	}
+}
+
+func TestSessionManager_RestartsExitedSession(t *testing.T) {
+	fake := &fakeSessions{t: t}
+	m := NewSessionManager(SessionOptions{NewClient: fake.newClient})
+	defer m.Close()
+
+	ctx := testContext(t)
+	first, release, err := m.Acquire(ctx, "/workspace/a")
+	if err != nil {
+		t.Fatalf("Failed to acquire session: %v", err)
+	}
+	release()
+
+	// terraform-ls exits
+	fake.mu.Lock()
+	fake.servers[0].Close()
+	fake.mu.Unlock()
+	<-first.lspClient.Done()
+
+	second, release, err := m.Acquire(ctx, "/workspace/a")
+	if err != nil {
+		t.Fatalf("Failed to acquire session after exit: %v", err)
+	}
+	defer release()
+
+	if second == first {
+		t.Error("Expected a new client after terraform-ls exited")
+	}
+	if got := fake.count(); got != 2 {
+		t.Errorf("Expected terraform-ls to be started again, got %d starts", got)
+	}
+	if sessions := m.Sessions(); len(sessions) != 1 {
+		t.Errorf("Expected 1 session, got: %v", sessions)
+	}
+}
+
+func TestClient_InitializeTwice(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"capabilities": map[string]interface{}{}}, nil
+	})
+
+	ctx := testContext(t)
+	if err := client.Initialize(ctx, "/workspace/a"); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if err := client.Initialize(ctx, "/workspace/a"); err != nil {
+		t.Errorf("Expected re-initializing the same workspace to succeed, got: %v", err)
+	}
+	if err := client.Initialize(ctx, "/workspace/b"); !errors.Is(err, ErrAlreadyInitialized) {
+		t.Errorf("Expected ErrAlreadyInitialized, got: %v", err)
+	}
+	if got := len(server.Received("initialize")); got != 1 {
+		t.Errorf("Expected 1 initialize request, got: %d", got)
+	}
+}
+
+func TestClient_InitializeFailureRecordsNoRoot(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return nil, errors.New("workspace not supported")
+	})
+
+	ctx := testContext(t)
+	if err := client.Initialize(ctx, "/workspace/a"); err == nil {
+		t.Fatal("Expected initialize to fail")
+	}
+	if root := client.WorkspaceRoot(); root != "" {
+		t.Errorf("Expected no workspace root after a failed initialize, got: %s", root)
+	}
+
+	server.Handle("initialize", func(params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"capabilities": map[string]interface{}{}}, nil
+	})
+	if err := client.Initialize(ctx, "/workspace/b"); err != nil {
+		t.Fatalf("Expected initialize to succeed after a failure, got: %v", err)
+	}
+	if root := client.WorkspaceRoot(); root != "/workspace/b" {
+		t.Errorf("Expected workspace root /workspace/b, got: %s", root)
+	}
+}
@@ -0,0 +1,156 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SignatureHelp returns the signature of the function call around a
+// position, such as inside cidrsubnet( or lookup(. It returns nil if the
+// position is not inside a function call.
+func (c *Client) SignatureHelp(ctx context.Context, uri, content string, line, character int) (*SignatureHelp, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/signatureHelp", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signature help: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("signature help error: %s", resp.Error.Message)
+	}
+
+	var help *SignatureHelp
+	if err := resp.UnmarshalResult(&help); err != nil {
+		return nil, fmt.Errorf("failed to decode signature help: %w", err)
+	}
+	if help == nil || len(help.Signatures) == 0 {
+		return nil, nil
+	}
+
+	return help, nil
+}
+
+// Active returns the active signature and the index of its active
+// parameter, or -1 if no parameter is active
+func (h *SignatureHelp) Active() (SignatureInformation, int) {
+	index := h.ActiveSignature
+	if index < 0 || index >= len(h.Signatures) {
+		index = 0
+	}
+	signature := h.Signatures[index]
+
+	active := h.ActiveParameter
+	if signature.ActiveParameter != nil {
+		active = *signature.ActiveParameter
+	}
+	if active < 0 || active >= len(signature.Parameters) {
+		active = -1
+	}
+	return signature, active
+}
+
+// UnmarshalJSON decodes a signature, resolving documentation that may be a
+// string or MarkupContent, and parameter labels given as offsets
+func (s *SignatureInformation) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Label         string          `json:"label"`
+		Documentation json.RawMessage `json:"documentation"`
+		Parameters    []struct {
+			Label         json.RawMessage `json:"label"`
+			Documentation json.RawMessage `json:"documentation"`
+		} `json:"parameters"`
+		ActiveParameter *int `json:"activeParameter"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	doc, err := documentationText(raw.Documentation)
+	if err != nil {
+		return fmt.Errorf("invalid documentation: %w", err)
+	}
+
+	*s = SignatureInformation{
+		Label:           raw.Label,
+		Documentation:   doc,
+		ActiveParameter: raw.ActiveParameter,
+	}
+
+	for _, p := range raw.Parameters {
+		label, err := parameterLabel(raw.Label, p.Label)
+		if err != nil {
+			return fmt.Errorf("invalid parameter label: %w", err)
+		}
+		doc, err := documentationText(p.Documentation)
+		if err != nil {
+			return fmt.Errorf("invalid parameter documentation: %w", err)
+		}
+		s.Parameters = append(s.Parameters, ParameterInformation{Label: label, Documentation: doc})
+	}
+
+	return nil
+}
+
+// parameterLabel returns the text of a parameter label, which is either a
+// string or a pair of UTF-16 offsets into the signature label
+func parameterLabel(signature string, data json.RawMessage) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		var label string
+		if err := json.Unmarshal(data, &label); err != nil {
+			return "", err
+		}
+		return label, nil
+	}
+
+	var offsets [2]int
+	if err := json.Unmarshal(data, &offsets); err != nil {
+		return "", err
+	}
+
+	idx := newLineIndex(signature)
+	start, err := idx.offset(Position{Character: offsets[0]})
+	if err != nil {
+		return "", err
+	}
+	end, err := idx.offset(Position{Character: offsets[1]})
+	if err != nil {
+		return "", err
+	}
+	if end < start {
+		return "", fmt.Errorf("invalid offsets %v", offsets)
+	}
+	return signature[start:end], nil
+}
+
+// documentationText decodes documentation that is either a plain string or
+// MarkupContent
+func documentationText(data json.RawMessage) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return "", nil
+	}
+
+	if data[0] == '"' {
+		var text string
+		err := json.Unmarshal(data, &text)
+		return text, err
+	}
+
+	var markup MarkupContent
+	err := json.Unmarshal(data, &markup)
+	return markup.Value, err
+}
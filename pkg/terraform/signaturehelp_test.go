@@ -0,0 +1,83 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSignatureInformation_UnmarshalJSON(t *testing.T) {
+	var signature SignatureInformation
+	err := json.Unmarshal([]byte(`{
+		"label": "cidrsubnet(prefix string, newbits number, netnum number) string",
+		"documentation": {"kind": "markdown", "value": "Calculates a subnet address"},
+		"parameters": [
+			{"label": [11, 24], "documentation": "CIDR prefix"},
+			{"label": "newbits number", "documentation": {"kind": "plaintext", "value": "Bits to add"}},
+			{"label": [42, 55]}
+		],
+		"activeParameter": 1
+	}`), &signature)
+	if err != nil {
+		t.Fatalf("Failed to decode signature: %v", err)
+	}
+
+	if signature.Documentation != "Calculates a subnet address" {
+		t.Errorf("Unexpected documentation: %q", signature.Documentation)
+	}
+	labels := []string{"prefix string", "newbits number", "netnum number"}
+	if len(signature.Parameters) != len(labels) {
+		t.Fatalf("Expected %d parameters, got: %d", len(labels), len(signature.Parameters))
+	}
+	for i, label := range labels {
+		if signature.Parameters[i].Label != label {
+			t.Errorf("Parameter %d: expected %q, got: %q", i, label, signature.Parameters[i].Label)
+		}
+	}
+	if signature.Parameters[1].Documentation != "Bits to add" {
+		t.Errorf("Unexpected parameter documentation: %q", signature.Parameters[1].Documentation)
+	}
+}
+
+func TestClient_SignatureHelp(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/signatureHelp", func(params json.RawMessage) (interface{}, error) {
+		var p TextDocumentPositionParams
+		json.Unmarshal(params, &p)
+
+		if p.Position.Character < 10 {
+			return nil, nil
+		}
+		return json.RawMessage(`{
+			"signatures": [{
+				"label": "lookup(inputMap map of any single type, key string, default any) any",
+				"parameters": [{"label": "inputMap map of any single type"}, {"label": "key string"}, {"label": "default any"}]
+			}],
+			"activeSignature": 0,
+			"activeParameter": 1
+		}`), nil
+	})
+
+	uri := "file:///workspace/main.tf"
+	content := "locals {\n  a = lookup(var.m, \n}\n"
+
+	help, err := client.SignatureHelp(testContext(t), uri, content, 1, 19)
+	if err != nil {
+		t.Fatalf("Failed to get signature help: %v", err)
+	}
+	if help == nil {
+		t.Fatal("Expected signature help")
+	}
+
+	signature, active := help.Active()
+	if active != 1 || signature.Parameters[active].Label != "key string" {
+		t.Errorf("Expected the key parameter to be active, got: %d", active)
+	}
+
+	help, err = client.SignatureHelp(testContext(t), uri, content, 1, 2)
+	if err != nil {
+		t.Fatalf("Failed to get signature help: %v", err)
+	}
+	if help != nil {
+		t.Errorf("Expected no signature help outside a call, got: %+v", help)
+	}
+}
@@ -0,0 +1,222 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ryu-ch/terraform-ls-mcp/pkg/lsp"
+)
+
+var (
+	// attributePattern matches the start of an attribute: a name and a
+	// single '='
+	attributePattern = regexp.MustCompile(`^[A-Za-z_][\w-]*[ \t]*=($|[^=])`)
+
+	// blockHeaderPattern matches a block type followed by its labels
+	blockHeaderPattern = regexp.MustCompile(`^[A-Za-z_][\w-]*(\s+("[^"\n]*"|[A-Za-z_][\w-]*))*\s*$`)
+)
+
+// FoldingRanges gets the foldable regions of a document, sorted by start
+// line. If the server does not support folding ranges, blocks and
+// attributes spanning several lines are found in the text instead.
+func (c *Client) FoldingRanges(ctx context.Context, uri, content string) ([]FoldingRange, error) {
+	// Open document
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/foldingRange", FoldingRangeParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folding ranges: %w", err)
+	}
+
+	if resp.Error != nil {
+		if resp.Error.Code == lsp.CodeMethodNotFound {
+			return foldingRangesFromText(content), nil
+		}
+		return nil, fmt.Errorf("folding range error: %s", resp.Error.Message)
+	}
+
+	var ranges []FoldingRange
+	if err := resp.UnmarshalResult(&ranges); err != nil {
+		return nil, fmt.Errorf("failed to decode folding ranges: %w", err)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].StartLine < ranges[j].StartLine
+	})
+	return ranges, nil
+}
+
+// SelectionRanges gets the selection ranges around each position. If the
+// server does not support selection ranges, they are built from the blocks
+// and attributes found in the text.
+func (c *Client) SelectionRanges(ctx context.Context, uri, content string, positions []Position) ([]SelectionRange, error) {
+	// Open document
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/selectionRange", SelectionRangeParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Positions: positions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get selection ranges: %w", err)
+	}
+
+	if resp.Error != nil {
+		if resp.Error.Code == lsp.CodeMethodNotFound {
+			return selectionRangesFromText(content, positions)
+		}
+		return nil, fmt.Errorf("selection range error: %s", resp.Error.Message)
+	}
+
+	var ranges []SelectionRange
+	if err := resp.UnmarshalResult(&ranges); err != nil {
+		return nil, fmt.Errorf("failed to decode selection ranges: %w", err)
+	}
+	return ranges, nil
+}
+
+// EnclosingRanges returns the blocks, attribute and expression around a
+// position, with their text. The selection ranges at the position are
+// classified by their text: a block is a header followed by a braced body,
+// and an attribute is a name followed by '='. The expression is the value of
+// the attribute.
+func (c *Client) EnclosingRanges(ctx context.Context, uri, content string, line, character int) (*EnclosingRanges, error) {
+	ranges, err := c.SelectionRanges(ctx, uri, content, []Position{{Line: line, Character: character}})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnclosingRanges{}
+	if len(ranges) == 0 {
+		return result, nil
+	}
+
+	idx := newLineIndex(content)
+	for sel := &ranges[0]; sel != nil; sel = sel.Parent {
+		start, err := idx.offset(sel.Range.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := idx.offset(sel.Range.End)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			continue
+		}
+		text := content[start:end]
+
+		switch {
+		case result.Attribute == nil && len(result.Blocks) == 0 && attributePattern.MatchString(text):
+			result.Attribute = &TextRange{Range: sel.Range, Text: text}
+
+			// The value starts after '=' and any blanks
+			exprStart := start + strings.IndexByte(text, '=') + 1
+			for exprStart < end && (content[exprStart] == ' ' || content[exprStart] == '\t') {
+				exprStart++
+			}
+			result.Expression = &TextRange{
+				Range: Range{Start: idx.position(exprStart), End: sel.Range.End},
+				Text:  content[exprStart:end],
+			}
+		case isBlockText(text):
+			// Servers may end the chain with the whole document, which is
+			// the same block when the file has only one
+			trimmed := strings.TrimSpace(text)
+			if n := len(result.Blocks); n > 0 && strings.TrimSpace(result.Blocks[n-1].Text) == trimmed {
+				continue
+			}
+			result.Blocks = append(result.Blocks, TextRange{Range: sel.Range, Text: text})
+		}
+	}
+
+	return result, nil
+}
+
+// isBlockText reports whether text is a single block: a block type and
+// labels followed by a braced body
+func isBlockText(text string) bool {
+	text = strings.TrimSpace(text)
+	open := strings.IndexByte(text, '{')
+	if open < 0 || !strings.HasSuffix(text, "}") {
+		return false
+	}
+	nodes := scanHCL(text)
+	return len(nodes) == 1 && nodes[0].block && blockHeaderPattern.MatchString(text[:open])
+}
+
+// foldingRangesFromText returns a folding range for each block and attribute
+// that spans several lines
+func foldingRangesFromText(content string) []FoldingRange {
+	idx := newLineIndex(content)
+	ranges := []FoldingRange{}
+
+	var walk func(nodes []*hclNode)
+	walk = func(nodes []*hclNode) {
+		for _, node := range nodes {
+			start, end := idx.position(node.start), idx.position(node.end)
+			if end.Line > start.Line {
+				startCharacter, endCharacter := start.Character, end.Character
+				ranges = append(ranges, FoldingRange{
+					StartLine:      start.Line,
+					StartCharacter: &startCharacter,
+					EndLine:        end.Line,
+					EndCharacter:   &endCharacter,
+				})
+			}
+			walk(node.children)
+		}
+	}
+	walk(scanHCL(content))
+
+	return ranges
+}
+
+// selectionRangesFromText builds the selection ranges around each position
+// from the blocks and attributes found in the text. Each chain goes from the
+// value of the attribute at the position, through the attribute and the
+// enclosing blocks, up to the whole document.
+func selectionRangesFromText(content string, positions []Position) ([]SelectionRange, error) {
+	idx := newLineIndex(content)
+	nodes := scanHCL(content)
+
+	ranges := make([]SelectionRange, 0, len(positions))
+	for _, pos := range positions {
+		offset, err := idx.offset(pos)
+		if err != nil {
+			return nil, err
+		}
+
+		// Build the chain outermost first, then link it innermost first
+		chain := []Range{{Start: idx.position(0), End: idx.position(len(content))}}
+		path := hclPath(nodes, offset)
+		for i := len(path) - 1; i >= 0; i-- {
+			node := path[i]
+			chain = append(chain, Range{Start: idx.position(node.start), End: idx.position(node.end)})
+			if !node.block && node.exprStart <= offset && offset <= node.exprEnd && node.exprEnd > node.exprStart {
+				chain = append(chain, Range{Start: idx.position(node.exprStart), End: idx.position(node.exprEnd)})
+			}
+		}
+
+		var sel *SelectionRange
+		for _, r := range chain {
+			sel = &SelectionRange{Range: r, Parent: sel}
+		}
+		ranges = append(ranges, *sel)
+	}
+
+	return ranges, nil
+}
@@ -0,0 +1,133 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const structureTestContent = `# Web server
+resource "aws_instance" "web" {
+  ami = var.ami_id
+  tags = {
+    Name = "web-${var.env}" # not a brace: }
+  }
+
+  user_data = <<-EOF
+    echo "{"
+  EOF
+
+  ebs_block_device {
+    volume_size = 10
+  }
+}
+
+variable "ami_id" {}
+`
+
+func TestScanHCL(t *testing.T) {
+	nodes := scanHCL(structureTestContent)
+	if len(nodes) != 2 {
+		t.Fatalf("Expected 2 top-level blocks, got: %d", len(nodes))
+	}
+
+	resource := nodes[0]
+	if got := structureTestContent[resource.start:resource.end]; got[:10] != "resource \"" || got[len(got)-1] != '}' {
+		t.Errorf("Unexpected resource block: %q", got)
+	}
+	if len(resource.children) != 4 {
+		t.Fatalf("Expected 4 items in the resource body, got: %d", len(resource.children))
+	}
+
+	tags := resource.children[1]
+	if got := structureTestContent[tags.exprStart:tags.exprEnd]; got != "{\n    Name = \"web-${var.env}\" # not a brace: }\n  }" {
+		t.Errorf("Unexpected tags value: %q", got)
+	}
+	userData := resource.children[2]
+	if got := structureTestContent[userData.exprStart:userData.exprEnd]; got != "<<-EOF\n    echo \"{\"\n  EOF" {
+		t.Errorf("Unexpected heredoc value: %q", got)
+	}
+	if device := resource.children[3]; !device.block || len(device.children) != 1 {
+		t.Errorf("Expected a nested block with one attribute, got: %+v", device)
+	}
+}
+
+func TestClient_EnclosingRangesFallsBackToText(t *testing.T) {
+	_, client := newTestClient(t)
+
+	result, err := client.EnclosingRanges(testContext(t), "file:///workspace/main.tf", structureTestContent, 12, 8)
+	if err != nil {
+		t.Fatalf("Failed to get enclosing ranges: %v", err)
+	}
+
+	if len(result.Blocks) != 2 {
+		t.Fatalf("Expected the nested and the resource block, got: %+v", result.Blocks)
+	}
+	if got := result.Blocks[0].Text; got != "ebs_block_device {\n    volume_size = 10\n  }" {
+		t.Errorf("Unexpected nested block: %q", got)
+	}
+	if r := result.Blocks[1].Range; r.Start != (Position{Line: 1}) || r.End != (Position{Line: 14, Character: 1}) {
+		t.Errorf("Unexpected resource block range: %+v", r)
+	}
+	if result.Attribute == nil || result.Attribute.Text != "volume_size = 10" {
+		t.Errorf("Unexpected attribute: %+v", result.Attribute)
+	}
+	if result.Expression == nil || result.Expression.Text != "10" || result.Expression.Range.Start.Character != 18 {
+		t.Errorf("Unexpected expression: %+v", result.Expression)
+	}
+}
+
+func TestClient_EnclosingRangesFromServer(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/selectionRange", func(params json.RawMessage) (interface{}, error) {
+		return json.RawMessage(`[{
+			"range": {"start": {"line": 1, "character": 8}, "end": {"line": 1, "character": 12}},
+			"parent": {
+				"range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 12}},
+				"parent": {
+					"range": {"start": {"line": 0, "character": 0}, "end": {"line": 2, "character": 1}},
+					"parent": {"range": {"start": {"line": 0, "character": 0}, "end": {"line": 3, "character": 0}}}
+				}
+			}
+		}]`), nil
+	})
+
+	content := "locals {\n  name = \"a\"\n}\n"
+	result, err := client.EnclosingRanges(testContext(t), "file:///workspace/main.tf", content, 1, 9)
+	if err != nil {
+		t.Fatalf("Failed to get enclosing ranges: %v", err)
+	}
+
+	if len(result.Blocks) != 1 || result.Blocks[0].Text != "locals {\n  name = \"a\"\n}" {
+		t.Errorf("Expected the locals block once, got: %+v", result.Blocks)
+	}
+	if result.Attribute == nil || result.Attribute.Text != "name = \"a\"" {
+		t.Errorf("Unexpected attribute: %+v", result.Attribute)
+	}
+	if result.Expression == nil || result.Expression.Text != "\"a\"" {
+		t.Errorf("Unexpected expression: %+v", result.Expression)
+	}
+}
+
+func TestClient_FoldingRangesFallsBackToText(t *testing.T) {
+	_, client := newTestClient(t)
+
+	ranges, err := client.FoldingRanges(testContext(t), "file:///workspace/main.tf", structureTestContent)
+	if err != nil {
+		t.Fatalf("Failed to get folding ranges: %v", err)
+	}
+
+	var lines [][2]int
+	for _, r := range ranges {
+		lines = append(lines, [2]int{r.StartLine, r.EndLine})
+	}
+	want := [][2]int{{1, 14}, {3, 5}, {7, 9}, {11, 13}}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected folding ranges %v, got: %v", want, lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Expected folding ranges %v, got: %v", want, lines)
+			break
+		}
+	}
+}
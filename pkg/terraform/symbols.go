@@ -0,0 +1,175 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// symbolKinds maps LSP SymbolKind values to names
+var symbolKinds = []string{
+	1: "file", 2: "module", 3: "namespace", 4: "package", 5: "class",
+	6: "method", 7: "property", 8: "field", 9: "constructor", 10: "enum",
+	11: "interface", 12: "function", 13: "variable", 14: "constant", 15: "string",
+	16: "number", 17: "boolean", 18: "array", 19: "object", 20: "key",
+	21: "null", 22: "enum member", 23: "struct", 24: "event", 25: "operator",
+	26: "type parameter",
+}
+
+// SymbolKindName returns a readable name for a SymbolKind
+func SymbolKindName(kind int) string {
+	if kind > 0 && kind < len(symbolKinds) {
+		return symbolKinds[kind]
+	}
+	return ""
+}
+
+// DocumentSymbols returns the outline of a document: its blocks, such as
+// resources, data sources, variables, outputs, locals and module calls, with
+// their attributes and nested blocks as children
+func (c *Client) DocumentSymbols(ctx context.Context, uri, content string) ([]DocumentSymbol, error) {
+	if _, err := c.openDocument(ctx, uri, content); err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	resp, err := c.lspClient.SendRequest(ctx, "textDocument/documentSymbol", DocumentSymbolParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document symbols: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("document symbol error: %s", resp.Error.Message)
+	}
+
+	var raw json.RawMessage
+	if err := resp.UnmarshalResult(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read document symbol result: %w", err)
+	}
+
+	symbols, err := decodeDocumentSymbols(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document symbols: %w", err)
+	}
+
+	return symbols, nil
+}
+
+// WorkspaceSymbols returns the symbols matching query in every file of the
+// workspace. An empty query matches all symbols. Symbols are sorted by file
+// and position.
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]SymbolInformation, error) {
+	resp, err := c.lspClient.SendRequest(ctx, "workspace/symbol", WorkspaceSymbolParams{
+		Query: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace symbols: %w", err)
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("workspace symbol error: %s", resp.Error.Message)
+	}
+
+	var symbols []SymbolInformation
+	if err := resp.UnmarshalResult(&symbols); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace symbols: %w", err)
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		a, b := symbols[i].Location, symbols[j].Location
+		if a.URI != b.URI {
+			return a.URI < b.URI
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		return a.Range.Start.Character < b.Range.Start.Character
+	})
+
+	return symbols, nil
+}
+
+// decodeDocumentSymbols decodes a textDocument/documentSymbol result, which
+// is either a DocumentSymbol tree or a flat SymbolInformation list. A flat
+// list is nested by container name where possible.
+func decodeDocumentSymbols(data json.RawMessage) ([]DocumentSymbol, error) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	var probe struct {
+		Location *Location `json:"location"`
+	}
+	if err := json.Unmarshal(parts[0], &probe); err != nil {
+		return nil, err
+	}
+
+	if probe.Location == nil {
+		var symbols []DocumentSymbol
+		if err := json.Unmarshal(data, &symbols); err != nil {
+			return nil, err
+		}
+		return symbols, nil
+	}
+
+	var infos []SymbolInformation
+	if err := json.Unmarshal(data, &infos); err != nil {
+		return nil, err
+	}
+	return NestSymbols(infos), nil
+}
+
+// NestSymbols converts a flat symbol list to a tree, placing each symbol
+// under the first preceding symbol named by its container name
+func NestSymbols(infos []SymbolInformation) []DocumentSymbol {
+	type node struct {
+		symbol   DocumentSymbol
+		children []int
+	}
+
+	nodes := make([]node, len(infos))
+	byName := make(map[string]int)
+	var roots []int
+
+	for i, info := range infos {
+		nodes[i].symbol = DocumentSymbol{
+			Name:           info.Name,
+			Kind:           info.Kind,
+			Deprecated:     info.Deprecated,
+			Range:          info.Location.Range,
+			SelectionRange: info.Location.Range,
+		}
+
+		if parent, ok := byName[info.ContainerName]; ok && info.ContainerName != "" {
+			nodes[parent].children = append(nodes[parent].children, i)
+		} else {
+			roots = append(roots, i)
+		}
+		if _, exists := byName[info.Name]; !exists {
+			byName[info.Name] = i
+		}
+	}
+
+	var build func(i int) DocumentSymbol
+	build = func(i int) DocumentSymbol {
+		symbol := nodes[i].symbol
+		for _, child := range nodes[i].children {
+			symbol.Children = append(symbol.Children, build(child))
+		}
+		return symbol
+	}
+
+	symbols := make([]DocumentSymbol, len(roots))
+	for i, root := range roots {
+		symbols[i] = build(root)
+	}
+	return symbols
+}
@@ -0,0 +1,77 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeDocumentSymbols_Flat(t *testing.T) {
+	symbols, err := decodeDocumentSymbols(json.RawMessage(`[
+		{"name": "locals", "kind": 5, "location": {"uri": "file:///workspace/main.tf", "range": {"start": {"line": 0, "character": 0}, "end": {"line": 3, "character": 1}}}},
+		{"name": "region", "kind": 15, "containerName": "locals", "location": {"uri": "file:///workspace/main.tf", "range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 20}}}},
+		{"name": "output \"id\"", "kind": 5, "location": {"uri": "file:///workspace/main.tf", "range": {"start": {"line": 5, "character": 0}, "end": {"line": 7, "character": 1}}}}
+	]`))
+	if err != nil {
+		t.Fatalf("Failed to decode symbols: %v", err)
+	}
+
+	if len(symbols) != 2 {
+		t.Fatalf("Expected 2 top-level symbols, got: %d", len(symbols))
+	}
+	if len(symbols[0].Children) != 1 || symbols[0].Children[0].Name != "region" {
+		t.Errorf("Expected region nested under locals, got: %+v", symbols[0].Children)
+	}
+
+	none, err := decodeDocumentSymbols(json.RawMessage(`null`))
+	if err != nil || len(none) != 0 {
+		t.Errorf("Expected no symbols, got: %+v, %v", none, err)
+	}
+}
+
+func TestClient_DocumentAndWorkspaceSymbols(t *testing.T) {
+	server, client := newTestClient(t)
+	server.Handle("textDocument/documentSymbol", func(params json.RawMessage) (interface{}, error) {
+		return []DocumentSymbol{
+			{
+				Name:  "resource \"aws_instance\" \"web\"",
+				Kind:  5,
+				Range: Range{Start: Position{Line: 0}, End: Position{Line: 2, Character: 1}},
+				Children: []DocumentSymbol{
+					{Name: "ami", Kind: 15, Range: Range{Start: Position{Line: 1, Character: 2}, End: Position{Line: 1, Character: 12}}},
+				},
+			},
+		}, nil
+	})
+
+	var query string
+	server.Handle("workspace/symbol", func(params json.RawMessage) (interface{}, error) {
+		var p WorkspaceSymbolParams
+		json.Unmarshal(params, &p)
+		query = p.Query
+
+		return []SymbolInformation{
+			{Name: "variable \"b\"", Kind: 5, Location: Location{URI: "file:///workspace/variables.tf", Range: Range{Start: Position{Line: 4}}}},
+			{Name: "variable \"a\"", Kind: 5, Location: Location{URI: "file:///workspace/variables.tf", Range: Range{Start: Position{Line: 0}}}},
+			{Name: "module \"vpc\"", Kind: 2, Location: Location{URI: "file:///workspace/main.tf"}},
+		}, nil
+	})
+
+	symbols, err := client.DocumentSymbols(testContext(t), "file:///workspace/main.tf", "resource \"aws_instance\" \"web\" {\n  ami = \"x\"\n}\n")
+	if err != nil {
+		t.Fatalf("Failed to get document symbols: %v", err)
+	}
+	if len(symbols) != 1 || len(symbols[0].Children) != 1 {
+		t.Fatalf("Expected one resource with one attribute, got: %+v", symbols)
+	}
+
+	infos, err := client.WorkspaceSymbols(testContext(t), "variable")
+	if err != nil {
+		t.Fatalf("Failed to get workspace symbols: %v", err)
+	}
+	if query != "variable" {
+		t.Errorf("Expected query 'variable', got: %q", query)
+	}
+	if len(infos) != 3 || infos[0].Name != "module \"vpc\"" || infos[1].Name != "variable \"a\"" {
+		t.Errorf("Expected symbols sorted by file and position, got: %+v", infos)
+	}
+}
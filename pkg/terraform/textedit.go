@@ -0,0 +1,171 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrOverlappingEdits is returned when a set of text edits cannot be applied
+// because two of them modify the same part of the document
+var ErrOverlappingEdits = errors.New("overlapping text edits")
+
+// lineIndex maps between LSP positions and byte offsets in a document. LSP
+// positions count characters in UTF-16 code units, so columns differ from
+// byte offsets for any non-ASCII text.
+type lineIndex struct {
+	content string
+	starts  []int
+}
+
+func newLineIndex(content string) *lineIndex {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &lineIndex{content: content, starts: starts}
+}
+
+// lineEnd returns the offset of the end of a line, excluding its line break
+func (idx *lineIndex) lineEnd(line int) int {
+	if line+1 >= len(idx.starts) {
+		return len(idx.content)
+	}
+
+	end := idx.starts[line+1] - 1
+	if end > idx.starts[line] && idx.content[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+// offset converts a position to a byte offset. As the LSP specification
+// requires, a character past the end of a line refers to the end of that
+// line, and a line past the end of the document refers to its end.
+func (idx *lineIndex) offset(pos Position) (int, error) {
+	if pos.Line < 0 || pos.Character < 0 {
+		return 0, fmt.Errorf("invalid position %d:%d", pos.Line, pos.Character)
+	}
+	if pos.Line >= len(idx.starts) {
+		return len(idx.content), nil
+	}
+
+	offset := idx.starts[pos.Line]
+	end := idx.lineEnd(pos.Line)
+
+	for units := 0; offset < end && units < pos.Character; {
+		r, size := utf8.DecodeRuneInString(idx.content[offset:end])
+		n := utf16.RuneLen(r)
+		if n < 0 {
+			n = 1
+		}
+		if units+n > pos.Character {
+			// The position points inside a surrogate pair
+			break
+		}
+		units += n
+		offset += size
+	}
+
+	return offset, nil
+}
+
+// position converts a byte offset to an LSP position
+func (idx *lineIndex) position(offset int) Position {
+	if offset > len(idx.content) {
+		offset = len(idx.content)
+	}
+
+	line := sort.Search(len(idx.starts), func(i int) bool { return idx.starts[i] > offset }) - 1
+	character := 0
+	for _, r := range idx.content[idx.starts[line]:offset] {
+		n := utf16.RuneLen(r)
+		if n < 0 {
+			n = 1
+		}
+		character += n
+	}
+
+	return Position{Line: line, Character: character}
+}
+
+// PositionAt converts a byte offset in content to an LSP position
+func PositionAt(content string, offset int) Position {
+	return newLineIndex(content).position(offset)
+}
+
+// OffsetAt converts an LSP position in content to a byte offset
+func OffsetAt(content string, pos Position) (int, error) {
+	return newLineIndex(content).offset(pos)
+}
+
+// ApplyTextEdits applies LSP text edits to content. All ranges refer to the
+// original content, as in a textDocument/formatting result. Edits that start
+// at the same position are applied in the order given; edits whose ranges
+// overlap are rejected with ErrOverlappingEdits.
+func ApplyTextEdits(content string, edits []TextEdit) (string, error) {
+	if len(edits) == 0 {
+		return content, nil
+	}
+
+	type span struct {
+		start, end int
+		text       string
+	}
+
+	idx := newLineIndex(content)
+	spans := make([]span, 0, len(edits))
+
+	for _, edit := range edits {
+		start, err := idx.offset(edit.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := idx.offset(edit.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("invalid range %d:%d-%d:%d", edit.Range.Start.Line, edit.Range.Start.Character,
+				edit.Range.End.Line, edit.Range.End.Character)
+		}
+		spans = append(spans, span{start: start, end: end, text: edit.NewText})
+	}
+
+	// Insertions sort before a replacement starting at the same offset so
+	// that they do not count as overlapping it
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].start == spans[i].end && spans[j].start != spans[j].end
+	})
+
+	var b strings.Builder
+	b.Grow(len(content))
+
+	last := 0
+	for i, s := range spans {
+		if s.start < last {
+			prev := spans[i-1]
+			return "", fmt.Errorf("%w: %s and %s", ErrOverlappingEdits,
+				formatSpan(idx, prev.start, prev.end), formatSpan(idx, s.start, s.end))
+		}
+		b.WriteString(content[last:s.start])
+		b.WriteString(s.text)
+		last = s.end
+	}
+	b.WriteString(content[last:])
+
+	return b.String(), nil
+}
+
+func formatSpan(idx *lineIndex, start, end int) string {
+	s, e := idx.position(start), idx.position(end)
+	return fmt.Sprintf("%d:%d-%d:%d", s.Line, s.Character, e.Line, e.Character)
+}
@@ -0,0 +1,102 @@
+package terraform
+
+import (
+	"errors"
+	"testing"
+)
+
+func edit(startLine, startChar, endLine, endChar int, text string) TextEdit {
+	return TextEdit{
+		Range: Range{
+			Start: Position{Line: startLine, Character: startChar},
+			End:   Position{Line: endLine, Character: endChar},
+		},
+		NewText: text,
+	}
+}
+
+func TestApplyTextEdits(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		edits   []TextEdit
+		want    string
+	}{
+		{
+			name:    "single line replacement",
+			content: "ami=\"x\"\n",
+			edits:   []TextEdit{edit(0, 3, 0, 4, " = ")},
+			want:    "ami = \"x\"\n",
+		},
+		{
+			name:    "multi-line range",
+			content: "a {\n\n\n  b = 1\n}\n",
+			edits:   []TextEdit{edit(0, 3, 3, 0, "\n")},
+			want:    "a {\n  b = 1\n}\n",
+		},
+		{
+			name:    "edits given out of order",
+			content: "x=1\ny=2\n",
+			edits:   []TextEdit{edit(1, 1, 1, 2, " = "), edit(0, 1, 0, 2, " = ")},
+			want:    "x = 1\ny = 2\n",
+		},
+		{
+			name:    "insertions at the same position keep their order",
+			content: "b\n",
+			edits:   []TextEdit{edit(0, 0, 0, 0, "a"), edit(0, 0, 0, 0, "-"), edit(0, 0, 0, 1, "B")},
+			want:    "a-B\n",
+		},
+		{
+			name:    "utf-16 columns after a surrogate pair",
+			content: "name = \"😀x\"\n",
+			// The emoji occupies two UTF-16 code units
+			edits: []TextEdit{edit(0, 10, 0, 11, "y")},
+			want:  "name = \"😀y\"\n",
+		},
+		{
+			name:    "crlf line endings",
+			content: "a=1\r\nb=2\r\n",
+			edits:   []TextEdit{edit(0, 1, 0, 99, " = 1")},
+			want:    "a = 1\r\nb=2\r\n",
+		},
+		{
+			name:    "range past end of document",
+			content: "a\n",
+			edits:   []TextEdit{edit(0, 1, 5, 0, "\n\n")},
+			want:    "a\n\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ApplyTextEdits(tc.content, tc.edits)
+			if err != nil {
+				t.Fatalf("Failed to apply edits: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyTextEdits_Overlapping(t *testing.T) {
+	_, err := ApplyTextEdits("abcdef\n", []TextEdit{edit(0, 0, 0, 3, "x"), edit(0, 2, 0, 4, "y")})
+	if !errors.Is(err, ErrOverlappingEdits) {
+		t.Errorf("Expected ErrOverlappingEdits, got: %v", err)
+	}
+}
+
+func TestPositionAt(t *testing.T) {
+	content := "a\n😀b\n"
+
+	pos := PositionAt(content, len("a\n😀"))
+	if pos.Line != 1 || pos.Character != 2 {
+		t.Errorf("Expected 1:2, got: %d:%d", pos.Line, pos.Character)
+	}
+
+	offset, err := OffsetAt(content, pos)
+	if err != nil || offset != len("a\n😀") {
+		t.Errorf("Expected offset %d, got: %d (%v)", len("a\n😀"), offset, err)
+	}
+}
@@ -1,12 +1,14 @@
 package terraform
 
+import "encoding/json"
+
 // LSP related types for terraform-ls
 
 // InitializeParams represents LSP initialize parameters
 type InitializeParams struct {
 	ProcessID        interface{}        `json:"processId"`
-	RootURI          string            `json:"rootUri,omitempty"`
-	WorkspaceFolders []WorkspaceFolder `json:"workspaceFolders,omitempty"`
+	RootURI          string             `json:"rootUri,omitempty"`
+	WorkspaceFolders []WorkspaceFolder  `json:"workspaceFolders,omitempty"`
 	Capabilities     ClientCapabilities `json:"capabilities"`
 }
 
@@ -18,15 +20,67 @@ type WorkspaceFolder struct {
 
 // ClientCapabilities represents client capabilities
 type ClientCapabilities struct {
+	Workspace    *WorkspaceClientCapabilities    `json:"workspace,omitempty"`
 	TextDocument *TextDocumentClientCapabilities `json:"textDocument,omitempty"`
+	Window       *WindowClientCapabilities       `json:"window,omitempty"`
+	Experimental *ExperimentalClientCapabilities `json:"experimental,omitempty"`
+}
+
+// ExperimentalClientCapabilities represents the experimental client
+// capabilities terraform-ls understands. Setting ShowReferencesCommandID
+// enables its reference count code lenses.
+type ExperimentalClientCapabilities struct {
+	ShowReferencesCommandID string `json:"showReferencesCommandId,omitempty"`
+}
+
+// WorkspaceClientCapabilities represents workspace client capabilities
+type WorkspaceClientCapabilities struct {
+	Configuration    bool                             `json:"configuration,omitempty"`
+	WorkspaceFolders bool                             `json:"workspaceFolders,omitempty"`
+	WorkspaceEdit    *WorkspaceEditClientCapabilities `json:"workspaceEdit,omitempty"`
+}
+
+// WorkspaceEditClientCapabilities represents workspace edit client capabilities
+type WorkspaceEditClientCapabilities struct {
+	DocumentChanges bool `json:"documentChanges,omitempty"`
+}
+
+// WindowClientCapabilities represents window client capabilities
+type WindowClientCapabilities struct {
+	WorkDoneProgress bool `json:"workDoneProgress,omitempty"`
 }
 
 // TextDocumentClientCapabilities represents text document client capabilities
 type TextDocumentClientCapabilities struct {
-	Completion *CompletionClientCapabilities `json:"completion,omitempty"`
-	Hover      *HoverClientCapabilities      `json:"hover,omitempty"`
+	Completion     *CompletionClientCapabilities     `json:"completion,omitempty"`
+	Hover          *HoverClientCapabilities          `json:"hover,omitempty"`
+	Definition     *DefinitionClientCapabilities     `json:"definition,omitempty"`
+	Declaration    *DeclarationClientCapabilities    `json:"declaration,omitempty"`
+	DocumentSymbol *DocumentSymbolClientCapabilities `json:"documentSymbol,omitempty"`
+	Rename         *RenameClientCapabilities         `json:"rename,omitempty"`
+	CodeAction     *CodeActionClientCapabilities     `json:"codeAction,omitempty"`
+	SemanticTokens *SemanticTokensClientCapabilities `json:"semanticTokens,omitempty"`
+	SignatureHelp  *SignatureHelpClientCapabilities  `json:"signatureHelp,omitempty"`
+	FoldingRange   *FoldingRangeClientCapabilities   `json:"foldingRange,omitempty"`
+	SelectionRange *SelectionRangeClientCapabilities `json:"selectionRange,omitempty"`
+	DocumentLink   *DocumentLinkClientCapabilities   `json:"documentLink,omitempty"`
+}
+
+// DocumentLinkClientCapabilities represents document link client
+// capabilities
+type DocumentLinkClientCapabilities struct {
+	TooltipSupport bool `json:"tooltipSupport"`
 }
 
+// FoldingRangeClientCapabilities represents folding range client capabilities
+type FoldingRangeClientCapabilities struct {
+	LineFoldingOnly bool `json:"lineFoldingOnly"`
+}
+
+// SelectionRangeClientCapabilities represents selection range client
+// capabilities
+type SelectionRangeClientCapabilities struct{}
+
 // CompletionClientCapabilities represents completion client capabilities
 type CompletionClientCapabilities struct {
 	CompletionItem *CompletionItemClientCapabilities `json:"completionItem,omitempty"`
@@ -34,7 +88,32 @@ type CompletionClientCapabilities struct {
 
 // CompletionItemClientCapabilities represents completion item client capabilities
 type CompletionItemClientCapabilities struct {
-	SnippetSupport bool `json:"snippetSupport,omitempty"`
+	SnippetSupport      bool            `json:"snippetSupport,omitempty"`
+	DocumentationFormat []string        `json:"documentationFormat,omitempty"`
+	DeprecatedSupport   bool            `json:"deprecatedSupport,omitempty"`
+	ResolveSupport      *ResolveSupport `json:"resolveSupport,omitempty"`
+}
+
+// ResolveSupport lists the properties a client can resolve lazily
+type ResolveSupport struct {
+	Properties []string `json:"properties"`
+}
+
+// SignatureHelpClientCapabilities represents signature help client capabilities
+type SignatureHelpClientCapabilities struct {
+	SignatureInformation *SignatureInformationClientCapabilities `json:"signatureInformation,omitempty"`
+}
+
+// SignatureInformationClientCapabilities represents signature information client capabilities
+type SignatureInformationClientCapabilities struct {
+	DocumentationFormat    []string                                `json:"documentationFormat,omitempty"`
+	ParameterInformation   *ParameterInformationClientCapabilities `json:"parameterInformation,omitempty"`
+	ActiveParameterSupport bool                                    `json:"activeParameterSupport,omitempty"`
+}
+
+// ParameterInformationClientCapabilities represents parameter information client capabilities
+type ParameterInformationClientCapabilities struct {
+	LabelOffsetSupport bool `json:"labelOffsetSupport,omitempty"`
 }
 
 // HoverClientCapabilities represents hover client capabilities
@@ -42,6 +121,61 @@ type HoverClientCapabilities struct {
 	ContentFormat []string `json:"contentFormat,omitempty"`
 }
 
+// DefinitionClientCapabilities represents definition client capabilities
+type DefinitionClientCapabilities struct {
+	LinkSupport bool `json:"linkSupport,omitempty"`
+}
+
+// DeclarationClientCapabilities represents declaration client capabilities
+type DeclarationClientCapabilities struct {
+	LinkSupport bool `json:"linkSupport,omitempty"`
+}
+
+// DocumentSymbolClientCapabilities represents document symbol client capabilities
+type DocumentSymbolClientCapabilities struct {
+	HierarchicalDocumentSymbolSupport bool `json:"hierarchicalDocumentSymbolSupport,omitempty"`
+}
+
+// RenameClientCapabilities represents rename client capabilities
+type RenameClientCapabilities struct {
+	PrepareSupport bool `json:"prepareSupport,omitempty"`
+}
+
+// CodeActionClientCapabilities represents code action client capabilities
+type CodeActionClientCapabilities struct {
+	CodeActionLiteralSupport *CodeActionLiteralSupport `json:"codeActionLiteralSupport,omitempty"`
+	IsPreferredSupport       bool                      `json:"isPreferredSupport,omitempty"`
+	DisabledSupport          bool                      `json:"disabledSupport,omitempty"`
+	DataSupport              bool                      `json:"dataSupport,omitempty"`
+	ResolveSupport           *ResolveSupport           `json:"resolveSupport,omitempty"`
+}
+
+// CodeActionLiteralSupport lists the code action kinds a client understands
+type CodeActionLiteralSupport struct {
+	CodeActionKind CodeActionKindValueSet `json:"codeActionKind"`
+}
+
+// CodeActionKindValueSet represents a set of code action kinds
+type CodeActionKindValueSet struct {
+	ValueSet []string `json:"valueSet"`
+}
+
+// SemanticTokensClientCapabilities represents semantic tokens client
+// capabilities. Servers only report the token types and modifiers listed
+// here.
+type SemanticTokensClientCapabilities struct {
+	Requests       SemanticTokensRequests `json:"requests"`
+	TokenTypes     []string               `json:"tokenTypes"`
+	TokenModifiers []string               `json:"tokenModifiers"`
+	Formats        []string               `json:"formats"`
+}
+
+// SemanticTokensRequests lists the semantic token requests a client sends
+type SemanticTokensRequests struct {
+	Range bool `json:"range,omitempty"`
+	Full  bool `json:"full,omitempty"`
+}
+
 // TextDocumentIdentifier represents a text document identifier
 type TextDocumentIdentifier struct {
 	URI string `json:"uri"`
@@ -60,6 +194,100 @@ type DidOpenTextDocumentParams struct {
 	TextDocument TextDocumentItem `json:"textDocument"`
 }
 
+// VersionedTextDocumentIdentifier represents a text document at a version
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// TextDocumentContentChangeEvent represents a change to a text document. A
+// nil Range means Text replaces the whole document.
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// DidChangeTextDocumentParams represents parameters for textDocument/didChange
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// DidCloseTextDocumentParams represents parameters for textDocument/didClose
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// InitializeResult represents the result of the initialize request
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+}
+
+// ServerCapabilities represents the capabilities announced by the server
+type ServerCapabilities struct {
+	TextDocumentSync       *TextDocumentSyncOptions `json:"textDocumentSync,omitempty"`
+	ExecuteCommandProvider *ExecuteCommandOptions   `json:"executeCommandProvider,omitempty"`
+	SemanticTokensProvider *SemanticTokensOptions   `json:"semanticTokensProvider,omitempty"`
+
+	// Providers that are either a boolean or an options object
+	DocumentRangeFormattingProvider json.RawMessage `json:"documentRangeFormattingProvider,omitempty"`
+
+	DocumentOnTypeFormattingProvider *DocumentOnTypeFormattingOptions `json:"documentOnTypeFormattingProvider,omitempty"`
+}
+
+// DocumentOnTypeFormattingOptions lists the characters that trigger
+// on-type formatting
+type DocumentOnTypeFormattingOptions struct {
+	FirstTriggerCharacter string   `json:"firstTriggerCharacter"`
+	MoreTriggerCharacter  []string `json:"moreTriggerCharacter,omitempty"`
+}
+
+// SemanticTokensOptions describes the semantic tokens a server provides.
+// Range and Full are either a boolean or an options object.
+type SemanticTokensOptions struct {
+	Legend SemanticTokensLegend `json:"legend"`
+	Range  json.RawMessage      `json:"range,omitempty"`
+	Full   json.RawMessage      `json:"full,omitempty"`
+}
+
+// SemanticTokensLegend maps the indexes used in semantic token data to
+// token type and modifier names
+type SemanticTokensLegend struct {
+	TokenTypes     []string `json:"tokenTypes"`
+	TokenModifiers []string `json:"tokenModifiers"`
+}
+
+// ExecuteCommandOptions lists the commands a server can execute
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
+
+// Text document sync kinds
+const (
+	TextDocumentSyncNone        = 0
+	TextDocumentSyncFull        = 1
+	TextDocumentSyncIncremental = 2
+)
+
+// TextDocumentSyncOptions represents how the server wants documents synced
+type TextDocumentSyncOptions struct {
+	OpenClose bool `json:"openClose,omitempty"`
+	Change    int  `json:"change,omitempty"`
+}
+
+// UnmarshalJSON accepts both the TextDocumentSyncOptions object and the
+// older bare TextDocumentSyncKind number
+func (o *TextDocumentSyncOptions) UnmarshalJSON(data []byte) error {
+	var kind int
+	if err := json.Unmarshal(data, &kind); err == nil {
+		*o = TextDocumentSyncOptions{OpenClose: kind != TextDocumentSyncNone, Change: kind}
+		return nil
+	}
+
+	type options TextDocumentSyncOptions
+	return json.Unmarshal(data, (*options)(o))
+}
+
 // Position represents a position in a document
 type Position struct {
 	Line      int `json:"line"`
@@ -74,15 +302,72 @@ type Range struct {
 
 // DiagnosticParams represents parameters for textDocument/diagnostic
 type DiagnosticParams struct {
-	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	TextDocument     TextDocumentIdentifier `json:"textDocument"`
+	PreviousResultID string                 `json:"previousResultId,omitempty"`
 }
 
+// Diagnostic severities
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+// Diagnostic tags
+const (
+	DiagnosticTagUnnecessary = 1
+	DiagnosticTagDeprecated  = 2
+)
+
 // Diagnostic represents a diagnostic (error/warning/info)
 type Diagnostic struct {
-	Range    Range  `json:"range"`
-	Severity int    `json:"severity,omitempty"`
-	Source   string `json:"source,omitempty"`
-	Message  string `json:"message"`
+	Range              Range                          `json:"range"`
+	Severity           int                            `json:"severity,omitempty"`
+	Code               interface{}                    `json:"code,omitempty"`
+	CodeDescription    *CodeDescription               `json:"codeDescription,omitempty"`
+	Source             string                         `json:"source,omitempty"`
+	Message            string                         `json:"message"`
+	Tags               []int                          `json:"tags,omitempty"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+// CodeDescription represents a link to documentation for a diagnostic code
+type CodeDescription struct {
+	Href string `json:"href"`
+}
+
+// Location represents a range inside a document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// DiagnosticRelatedInformation represents a location related to a diagnostic
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// Document diagnostic report kinds
+const (
+	DiagnosticReportFull      = "full"
+	DiagnosticReportUnchanged = "unchanged"
+)
+
+// DocumentDiagnosticReport represents the result of textDocument/diagnostic
+type DocumentDiagnosticReport struct {
+	Kind             string                              `json:"kind"`
+	ResultID         string                              `json:"resultId,omitempty"`
+	Items            []Diagnostic                        `json:"items,omitempty"`
+	RelatedDocuments map[string]DocumentDiagnosticReport `json:"relatedDocuments,omitempty"`
+}
+
+// PublishDiagnosticsParams represents parameters for textDocument/publishDiagnostics
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
 }
 
 // DocumentFormattingParams represents parameters for textDocument/formatting
@@ -93,8 +378,28 @@ type DocumentFormattingParams struct {
 
 // FormattingOptions represents formatting options
 type FormattingOptions struct {
-	TabSize      int  `json:"tabSize"`
-	InsertSpaces bool `json:"insertSpaces"`
+	TabSize                int  `json:"tabSize"`
+	InsertSpaces           bool `json:"insertSpaces"`
+	TrimTrailingWhitespace bool `json:"trimTrailingWhitespace,omitempty"`
+	InsertFinalNewline     bool `json:"insertFinalNewline,omitempty"`
+	TrimFinalNewlines      bool `json:"trimFinalNewlines,omitempty"`
+}
+
+// DocumentRangeFormattingParams represents parameters for
+// textDocument/rangeFormatting
+type DocumentRangeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+// DocumentOnTypeFormattingParams represents parameters for
+// textDocument/onTypeFormatting
+type DocumentOnTypeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	Ch           string                 `json:"ch"`
+	Options      FormattingOptions      `json:"options"`
 }
 
 // TextEdit represents a text edit
@@ -103,37 +408,510 @@ type TextEdit struct {
 	NewText string `json:"newText"`
 }
 
+// TextDocumentPositionParams represents a position inside a text document
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// ReferenceParams represents parameters for textDocument/references
+type ReferenceParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	Context      ReferenceContext       `json:"context"`
+}
+
+// ReferenceContext represents the context of a references request
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+}
+
+// LocationLink represents a link from a source range to a target location
+type LocationLink struct {
+	OriginSelectionRange *Range `json:"originSelectionRange,omitempty"`
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// DocumentSymbolParams represents parameters for textDocument/documentSymbol
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// WorkspaceSymbolParams represents parameters for workspace/symbol
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+// DocumentSymbol represents a symbol in a document, such as a block or an
+// attribute, with its nested symbols
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Deprecated     bool             `json:"deprecated,omitempty"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// SymbolInformation represents a symbol found by workspace/symbol, or by
+// textDocument/documentSymbol on servers without hierarchical support
+type SymbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	Deprecated    bool     `json:"deprecated,omitempty"`
+	Location      Location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
+
+// RenameParams represents parameters for textDocument/rename
+type RenameParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	NewName      string                 `json:"newName"`
+}
+
+// PrepareRenameResult represents the result of textDocument/prepareRename.
+// When DefaultBehavior is set the server did not return a range, and the
+// identifier at the position is renamed.
+type PrepareRenameResult struct {
+	Range           Range  `json:"range"`
+	Placeholder     string `json:"placeholder,omitempty"`
+	DefaultBehavior bool   `json:"defaultBehavior,omitempty"`
+}
+
+// OptionalVersionedTextDocumentIdentifier identifies a document and, if
+// Version is set, the version an edit was computed against
+type OptionalVersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version *int   `json:"version"`
+}
+
+// TextDocumentEdit represents edits to a single version of a document
+type TextDocumentEdit struct {
+	TextDocument OptionalVersionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []TextEdit                              `json:"edits"`
+}
+
+// WorkspaceEdit represents changes to many documents. Servers use either
+// Changes or DocumentChanges; file create, rename and delete operations in
+// documentChanges are not supported.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []TextDocumentEdit    `json:"documentChanges,omitempty"`
+}
+
+// Code action kinds
+const (
+	CodeActionKindQuickFix                 = "quickfix"
+	CodeActionKindRefactor                 = "refactor"
+	CodeActionKindSource                   = "source"
+	CodeActionKindSourceFormatAll          = "source.formatAll"
+	CodeActionKindSourceFormatAllTerraform = "source.formatAll.terraform"
+)
+
+// CodeActionParams represents parameters for textDocument/codeAction
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+// CodeActionContext represents the diagnostics and kinds a code action
+// request is made for
+type CodeActionContext struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Only        []string     `json:"only,omitempty"`
+}
+
+// CodeAction represents a change the server suggests, such as a quick fix
+// or formatting the whole file. Actions may carry an edit, a command to
+// execute, or only Data to be resolved with codeAction/resolve.
+type CodeAction struct {
+	Title       string              `json:"title"`
+	Kind        string              `json:"kind,omitempty"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"`
+	IsPreferred bool                `json:"isPreferred,omitempty"`
+	Disabled    *CodeActionDisabled `json:"disabled,omitempty"`
+	Edit        *WorkspaceEdit      `json:"edit,omitempty"`
+	Command     *Command            `json:"command,omitempty"`
+	Data        json.RawMessage     `json:"data,omitempty"`
+}
+
+// CodeActionDisabled explains why a code action cannot be applied
+type CodeActionDisabled struct {
+	Reason string `json:"reason"`
+}
+
+// ExecuteCommandParams represents parameters for workspace/executeCommand
+type ExecuteCommandParams struct {
+	Command   string        `json:"command"`
+	Arguments []interface{} `json:"arguments,omitempty"`
+}
+
+// SemanticTokensParams represents parameters for
+// textDocument/semanticTokens/full
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// SemanticTokensRangeParams represents parameters for
+// textDocument/semanticTokens/range
+type SemanticTokensRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+// SemanticTokens represents encoded semantic tokens. Each token is five
+// integers: line delta, start character delta, length, token type index and
+// modifier bit set.
+type SemanticTokens struct {
+	ResultID string `json:"resultId,omitempty"`
+	Data     []int  `json:"data"`
+}
+
+// SemanticToken represents a decoded semantic token. Positions and Length
+// are in UTF-16 code units, as in LSP; Text is the source of the token.
+type SemanticToken struct {
+	Line      int      `json:"line"`
+	Character int      `json:"character"`
+	Length    int      `json:"length"`
+	Type      string   `json:"type"`
+	Modifiers []string `json:"modifiers,omitempty"`
+	Text      string   `json:"text"`
+}
+
+// SignatureHelp represents the signatures of the function call at a
+// position. Signature and parameter documentation is decoded to text
+// whether the server sends a string or MarkupContent.
+type SignatureHelp struct {
+	Signatures      []SignatureInformation `json:"signatures"`
+	ActiveSignature int                    `json:"activeSignature,omitempty"`
+	ActiveParameter int                    `json:"activeParameter,omitempty"`
+}
+
+// SignatureInformation represents one signature of a function. When set,
+// ActiveParameter overrides the one of the SignatureHelp.
+type SignatureInformation struct {
+	Label           string                 `json:"label"`
+	Documentation   string                 `json:"documentation,omitempty"`
+	Parameters      []ParameterInformation `json:"parameters,omitempty"`
+	ActiveParameter *int                   `json:"activeParameter,omitempty"`
+}
+
+// ParameterInformation represents a parameter of a signature. Label is the
+// parameter's text, also when the server sends it as offsets into the
+// signature label.
+type ParameterInformation struct {
+	Label         string `json:"label"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// CodeLensParams represents parameters for textDocument/codeLens
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// CodeLens represents a command shown above a range of source, such as the
+// number of references to a variable. Lenses without a command are filled in
+// by codeLens/resolve.
+type CodeLens struct {
+	Range   Range           `json:"range"`
+	Command *Command        `json:"command,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
 // CompletionParams represents parameters for textDocument/completion
 type CompletionParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 	Position     Position               `json:"position"`
 }
 
-// CompletionItem represents a completion item
+// Insert text formats
+const (
+	InsertTextFormatPlainText = 1
+	InsertTextFormatSnippet   = 2
+)
+
+// MarkupContent represents documentation in plaintext or markdown
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Command represents a command the server can execute
+type Command struct {
+	Title     string        `json:"title"`
+	Command   string        `json:"command"`
+	Arguments []interface{} `json:"arguments,omitempty"`
+}
+
+// CompletionList represents a textDocument/completion result
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// CompletionItem represents a completion item. Documentation holds the
+// documentation text whether the server sent a plain string or
+// MarkupContent; DocumentationFormat records which markup it uses. When the
+// server sends an InsertReplaceEdit, TextEdit holds its insert range and
+// ReplaceRange the range replaced instead.
 type CompletionItem struct {
-	Label         string `json:"label"`
-	Kind          int    `json:"kind,omitempty"`
-	Detail        string `json:"detail,omitempty"`
-	Documentation string `json:"documentation,omitempty"`
-	InsertText    string `json:"insertText,omitempty"`
+	Label               string          `json:"label"`
+	Kind                int             `json:"kind,omitempty"`
+	Detail              string          `json:"detail,omitempty"`
+	Documentation       string          `json:"documentation,omitempty"`
+	DocumentationFormat string          `json:"documentationFormat,omitempty"`
+	Deprecated          bool            `json:"deprecated,omitempty"`
+	Preselect           bool            `json:"preselect,omitempty"`
+	SortText            string          `json:"sortText,omitempty"`
+	FilterText          string          `json:"filterText,omitempty"`
+	InsertText          string          `json:"insertText,omitempty"`
+	InsertTextFormat    int             `json:"insertTextFormat,omitempty"`
+	TextEdit            *TextEdit       `json:"textEdit,omitempty"`
+	ReplaceRange        *Range          `json:"replaceRange,omitempty"`
+	AdditionalTextEdits []TextEdit      `json:"additionalTextEdits,omitempty"`
+	Command             *Command        `json:"command,omitempty"`
+	Data                json.RawMessage `json:"data,omitempty"`
+
+	// raw is the item as the server sent it, which is what resolving it
+	// sends back
+	raw json.RawMessage
 }
 
 // Result types for MCP
 
 // ValidationResult represents the result of document validation
 type ValidationResult struct {
-	URI         string       `json:"uri"`
-	Diagnostics []Diagnostic `json:"diagnostics"`
+	URI              string                  `json:"uri"`
+	Diagnostics      []Diagnostic            `json:"diagnostics"`
+	RelatedDocuments map[string][]Diagnostic `json:"relatedDocuments,omitempty"`
+	TimedOut         bool                    `json:"timedOut,omitempty"`
 }
 
 // FormatResult represents the result of document formatting
 type FormatResult struct {
-	URI   string     `json:"uri"`
-	Edits []TextEdit `json:"edits"`
+	URI       string     `json:"uri"`
+	Edits     []TextEdit `json:"edits"`
+	Formatted string     `json:"formatted"`
 }
 
 // CompletionResult represents the result of completion request
 type CompletionResult struct {
-	URI   string           `json:"uri"`
-	Items []CompletionItem `json:"items"`
-}
\ No newline at end of file
+	URI          string           `json:"uri"`
+	Items        []CompletionItem `json:"items"`
+	IsIncomplete bool             `json:"isIncomplete,omitempty"`
+}
+
+// HoverResult represents the result of a hover request. Contents is
+// markdown; it is empty if there is nothing to show at the position.
+type HoverResult struct {
+	URI      string `json:"uri"`
+	Contents string `json:"contents"`
+	Range    *Range `json:"range,omitempty"`
+}
+
+// LocationResult represents a location returned by a definition,
+// declaration or references request. Snippet holds the source lines around
+// the range, numbered from 1, and is empty if the file could not be read.
+type LocationResult struct {
+	URI     string `json:"uri"`
+	Path    string `json:"path"`
+	Range   Range  `json:"range"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// FileChange represents the effect of a WorkspaceEdit on one file
+type FileChange struct {
+	URI      string `json:"uri"`
+	Path     string `json:"path"`
+	Original string `json:"original"`
+	Content  string `json:"content"`
+	Edits    int    `json:"edits"`
+}
+
+// ModuleCallsResult represents the result of the terraform-ls.module.calls
+// command
+type ModuleCallsResult struct {
+	V           int          `json:"v"`
+	ModuleCalls []ModuleCall `json:"module_calls"`
+}
+
+// ModuleCall represents a module block and the module calls of the module
+// it refers to
+type ModuleCall struct {
+	Name             string       `json:"name"`
+	SourceAddr       string       `json:"source_addr"`
+	Version          string       `json:"version,omitempty"`
+	SourceType       string       `json:"source_type,omitempty"`
+	DocsLink         string       `json:"docs_link,omitempty"`
+	DependentModules []ModuleCall `json:"dependent_modules,omitempty"`
+}
+
+// ModuleProvidersResult represents the result of the
+// terraform-ls.module.providers command. Both maps are keyed by provider
+// address, such as registry.terraform.io/hashicorp/aws.
+type ModuleProvidersResult struct {
+	V                    int                            `json:"v"`
+	ProviderRequirements map[string]ProviderRequirement `json:"provider_requirements"`
+	InstalledProviders   map[string]string              `json:"installed_providers"`
+}
+
+// ProviderRequirement represents an entry of required_providers
+type ProviderRequirement struct {
+	DisplayName       string `json:"display_name"`
+	VersionConstraint string `json:"version_constraint,omitempty"`
+	DocsLink          string `json:"docs_link,omitempty"`
+}
+
+// ModuleCallersResult represents the result of the
+// terraform-ls.module.callers command
+type ModuleCallersResult struct {
+	V       int            `json:"v"`
+	Callers []ModuleCaller `json:"callers"`
+}
+
+// ModuleCaller represents a module that calls another module
+type ModuleCaller struct {
+	URI string `json:"uri"`
+}
+
+// ModuleTerraformResult represents the result of the
+// terraform-ls.module.terraform command
+type ModuleTerraformResult struct {
+	V                 int    `json:"v"`
+	RequiredVersion   string `json:"required_version,omitempty"`
+	DiscoveredVersion string `json:"discovered_version,omitempty"`
+}
+
+// ModuleCallTree is the module calls of a module, with the calls of each
+// called module nested below it, and the modules that call it
+type ModuleCallTree struct {
+	ModuleCalls []ModuleCallInfo `json:"moduleCalls"`
+	Callers     []ModuleCaller   `json:"callers"`
+}
+
+// ModuleCallInfo describes a module block and the module calls of the
+// module it refers to
+type ModuleCallInfo struct {
+	Name             string           `json:"name"`
+	SourceAddr       string           `json:"sourceAddr"`
+	Version          string           `json:"version,omitempty"`
+	SourceType       string           `json:"sourceType,omitempty"`
+	DocsLink         string           `json:"docsLink,omitempty"`
+	DependentModules []ModuleCallInfo `json:"dependentModules,omitempty"`
+}
+
+// ModuleProviderInfo is the provider requirements of a module, the provider
+// versions installed for it and its Terraform version. The maps are keyed
+// by provider address.
+type ModuleProviderInfo struct {
+	ProviderRequirements map[string]ProviderRequirementInfo `json:"providerRequirements"`
+	InstalledProviders   map[string]string                  `json:"installedProviders"`
+	Terraform            TerraformVersionInfo               `json:"terraform"`
+}
+
+// ProviderRequirementInfo describes an entry of required_providers
+type ProviderRequirementInfo struct {
+	DisplayName       string `json:"displayName"`
+	VersionConstraint string `json:"versionConstraint,omitempty"`
+	DocsLink          string `json:"docsLink,omitempty"`
+}
+
+// TerraformVersionInfo is the Terraform version a module requires and the
+// version terraform-ls found installed
+type TerraformVersionInfo struct {
+	RequiredVersion   string `json:"requiredVersion,omitempty"`
+	DiscoveredVersion string `json:"discoveredVersion,omitempty"`
+}
+
+// ModuleValidationResult represents the result of terraform validate for a
+// module. Diagnostics are keyed by document URI; documents without
+// diagnostics are omitted.
+type ModuleValidationResult struct {
+	URI         string                  `json:"uri"`
+	Diagnostics map[string][]Diagnostic `json:"diagnostics"`
+	TimedOut    bool                    `json:"timedOut,omitempty"`
+}
+
+// ReferenceCount represents how often a variable, output or local value is
+// referenced. Kind is "variable", "output" or "local".
+type ReferenceCount struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	Range      Range  `json:"range"`
+	References int    `json:"references"`
+}
+
+// FoldingRangeParams represents parameters for textDocument/foldingRange
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// FoldingRange is a foldable region of a document. EndLine is the line of
+// the last folded character; characters are unset when the server folds
+// whole lines.
+type FoldingRange struct {
+	StartLine      int    `json:"startLine"`
+	StartCharacter *int   `json:"startCharacter,omitempty"`
+	EndLine        int    `json:"endLine"`
+	EndCharacter   *int   `json:"endCharacter,omitempty"`
+	Kind           string `json:"kind,omitempty"`
+}
+
+// SelectionRangeParams represents parameters for textDocument/selectionRange
+type SelectionRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Positions    []Position             `json:"positions"`
+}
+
+// SelectionRange is a range around a position together with the ranges
+// enclosing it, innermost first
+type SelectionRange struct {
+	Range  Range           `json:"range"`
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
+
+// TextRange is a range of a document with the text it covers
+type TextRange struct {
+	Range Range  `json:"range"`
+	Text  string `json:"text"`
+}
+
+// EnclosingRanges are the structural ranges around a position. Blocks are
+// ordered innermost first, so the last one is the top-level block.
+type EnclosingRanges struct {
+	Blocks     []TextRange `json:"blocks,omitempty"`
+	Attribute  *TextRange  `json:"attribute,omitempty"`
+	Expression *TextRange  `json:"expression,omitempty"`
+}
+
+// DocumentLinkParams represents parameters for textDocument/documentLink
+type DocumentLinkParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// DocumentLink is a range of a document linked to a URL. Target may be left
+// out until the link is resolved.
+type DocumentLink struct {
+	Range   Range           `json:"range"`
+	Target  string          `json:"target,omitempty"`
+	Tooltip string          `json:"tooltip,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// BlockLink is a resource, data source or module block with the
+// documentation URL terraform-ls links it to. URL is empty when the block
+// has no link.
+type BlockLink struct {
+	Kind   string `json:"kind"`
+	Type   string `json:"type,omitempty"`
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+	Range  Range  `json:"range"`
+	URL    string `json:"url,omitempty"`
+}
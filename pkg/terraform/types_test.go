@@ -144,4 +144,4 @@ func TestValidationResult_Structure(t *testing.T) {
 	if len(unmarshaled.Diagnostics) != 1 {
 		t.Errorf("Expected 1 diagnostic, got: %d", len(unmarshaled.Diagnostics))
 	}
-}
\ No newline at end of file
+}
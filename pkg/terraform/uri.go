@@ -0,0 +1,30 @@
+package terraform
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// PathToURI converts an absolute file system path to a file URI. The path
+// is percent-encoded like the URIs terraform-ls sends, so that they can be
+// compared with the URIs of the documents opened.
+func PathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// URIToPath converts a file URI returned by terraform-ls to a file system
+// path. terraform-ls percent-encodes URIs, so characters such as spaces are
+// decoded. URIs that are not file URIs are returned unchanged.
+func URIToPath(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -0,0 +1,152 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// ErrStaleEdit is returned when a WorkspaceEdit was computed against a
+// document version that is no longer current
+var ErrStaleEdit = errors.New("workspace edit refers to an outdated document version")
+
+// ErrStaleFile is returned by ApplyFileChanges when a file on disk no longer
+// has the content the changes were computed from
+var ErrStaleFile = errors.New("file changed on disk since the edits were computed")
+
+// UnmarshalJSON decodes a workspace edit, rejecting documentChanges entries
+// that create, rename or delete files
+func (e *WorkspaceEdit) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Changes         map[string][]TextEdit `json:"changes"`
+		DocumentChanges []json.RawMessage     `json:"documentChanges"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	e.Changes = raw.Changes
+	e.DocumentChanges = nil
+
+	for _, change := range raw.DocumentChanges {
+		var op struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(change, &op); err != nil {
+			return err
+		}
+		if op.Kind != "" {
+			return fmt.Errorf("unsupported resource operation %q in workspace edit", op.Kind)
+		}
+
+		var edit TextDocumentEdit
+		if err := json.Unmarshal(change, &edit); err != nil {
+			return err
+		}
+		e.DocumentChanges = append(e.DocumentChanges, edit)
+	}
+
+	return nil
+}
+
+// ResolveWorkspaceEdit computes the new content of every file a workspace
+// edit touches, without writing anything. Files open in the server are
+// edited from their synced content, other files are read from disk. The
+// result is sorted by path.
+func (c *Client) ResolveWorkspaceEdit(edit WorkspaceEdit) ([]FileChange, error) {
+	edits := make(map[string][]TextEdit)
+	for uri, changes := range edit.Changes {
+		edits[uri] = append(edits[uri], changes...)
+	}
+	for _, change := range edit.DocumentChanges {
+		uri := change.TextDocument.URI
+		if v := change.TextDocument.Version; v != nil {
+			if current := c.DocumentVersion(uri); current != 0 && current != *v {
+				return nil, fmt.Errorf("%w: %s is at version %d, edit is for version %d", ErrStaleEdit, uri, current, *v)
+			}
+		}
+		edits[uri] = append(edits[uri], change.Edits...)
+	}
+
+	changes := make([]FileChange, 0, len(edits))
+	for uri, fileEdits := range edits {
+		original, err := c.readSource(uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", URIToPath(uri), err)
+		}
+
+		content, err := ApplyTextEdits(original, fileEdits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply edits to %s: %w", URIToPath(uri), err)
+		}
+
+		changes = append(changes, FileChange{
+			URI:      uri,
+			Path:     URIToPath(uri),
+			Original: original,
+			Content:  content,
+			Edits:    len(fileEdits),
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
+
+// ApplyFileChanges writes resolved changes to disk and syncs the documents
+// open in the server with their new content. held lists the documents the
+// caller has locked with LockDocument, which are synced as well; documents
+// locked by other callers are left to them. Files keep their permissions.
+// Every file is checked against the content the changes were computed from
+// before any is written, so that edits made on disk in the meantime are not
+// overwritten.
+func (c *Client) ApplyFileChanges(ctx context.Context, changes []FileChange, held ...string) error {
+	for _, change := range changes {
+		if change.Content == change.Original {
+			continue
+		}
+
+		data, err := os.ReadFile(change.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", change.Path, err)
+		}
+		if string(data) != change.Original {
+			return fmt.Errorf("%w: %s", ErrStaleFile, change.Path)
+		}
+	}
+
+	for _, change := range changes {
+		if change.Content == change.Original {
+			continue
+		}
+
+		info, err := os.Stat(change.Path)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", change.Path, err)
+		}
+		if err := os.WriteFile(change.Path, []byte(change.Content), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", change.Path, err)
+		}
+
+		if !slices.Contains(held, change.URI) {
+			if err := c.refreshDocument(ctx, change.URI); err != nil {
+				return err
+			}
+			continue
+		}
+		if c.DocumentVersion(change.URI) != 0 {
+			if _, err := c.openDocument(ctx, change.URI, change.Content); err != nil {
+				return fmt.Errorf("failed to sync %s: %w", change.Path, err)
+			}
+		}
+	}
+
+	return nil
+}